@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/dytlzl/tervi/pkg/color"
 	"github.com/dytlzl/tervi/pkg/tui"
 )
 
@@ -16,7 +17,7 @@ func rootView() *tui.View {
 		return tui.InlineStack(
 			tui.InlineMapN(16, func(j int) *tui.View {
 				seq := i*16 + j
-				return tui.Fmt("%4d", seq).FGColor(uint8(seq))
+				return tui.Fmt("%4d", seq).FGColor(color.Palette(uint8(seq)))
 			}),
 			tui.Break(),
 		)
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dytlzl/tervi/pkg/color"
+	"github.com/dytlzl/tervi/pkg/component"
+	"github.com/dytlzl/tervi/pkg/key"
+	"github.com/dytlzl/tervi/pkg/tui"
+)
+
+func main() {
+	name, namePosition := "", 0
+	email, emailPosition := "", 0
+	field := func(id, title string, input *string, position *int) *tui.View {
+		return tui.VStack(component.TextInput(input, position, func() {})).
+			Title(title).
+			Border(tui.BorderOptionFGColor(color.RGB(100, 100, 100))).
+			AbsoluteSize(0, 5).
+			Focusable(id).
+			Focused(func(v *tui.View) *tui.View {
+				return v.Border(tui.BorderOptionFGColor(color.RGB(200, 100, 200)))
+			})
+	}
+	err := tui.Run(func() *tui.View {
+		return tui.VStack(
+			field("name", "Name", &name, &namePosition),
+			field("email", "Email", &email, &emailPosition),
+			tui.String("Tab: next field, Enter: submit"),
+		).RelativeSize(8, 12).KeyHandler(func(r rune) any {
+			if r == key.Enter {
+				return tui.Terminate
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("name: %s, email: %s\n", name, email)
+}
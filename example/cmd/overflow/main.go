@@ -1,41 +1,63 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
 	"strings"
 
 	"github.com/dytlzl/tervi/pkg/key"
+	"github.com/dytlzl/tervi/pkg/keymap"
 	"github.com/dytlzl/tervi/pkg/tui"
 )
 
+var keys = keymap.MustNew(
+	keymap.Binding{Action: "up", Keys: []string{"k"}, Help: "up"},
+	keymap.Binding{Action: "down", Keys: []string{"j"}, Help: "down"},
+	keymap.Binding{Action: "top", Keys: []string{"g g"}, Help: "top"},
+	keymap.Binding{Action: "bottom", Keys: []string{"G"}, Help: "bottom"},
+	keymap.Binding{Action: "select", Keys: []string{"enter"}, Help: "print the name"},
+	keymap.Binding{Action: "help", Keys: []string{"?"}, Help: "toggle help"},
+)
+
 func main() {
-	err := tui.Run(renderList)
+	// The keys can be remapped in keymap.json, e.g. {"select": ["enter", "space"]}.
+	err := keys.LoadFile("keymap.json")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		panic(err)
+	}
+	p := tui.NewProgram(renderList, tui.OptionMouse())
+	err = p.Run(context.Background())
 	if err != nil {
 		panic(err)
 	}
-	if name != "" {
+	if name, ok := p.Result().(string); ok {
 		fmt.Println(name)
 	}
 }
 
-var name = ""
-
 func renderList() *tui.View {
 	selected := tui.UseRef(0)
+	isHelpShown, setHelpShown := tui.UseState(false)
 	files, _ := ioutil.ReadDir(".")
-	return tui.ZStack(tui.ListMap(selected, files, func(file fs.FileInfo) *tui.View {
-		return tui.HStack(tui.String(file.Name()).AbsoluteSize(20, 1), tui.Fmt("%d", file.Size()))
-	}).Border().Padding(1, 2)).KeyHandler(func(r rune) any {
-		switch r {
-		case key.Enter:
-			name = files[*selected].Name()
-			return tui.Terminate
-		default:
-			return nil
-		}
-	})
+	return tui.ZStack(
+		tui.VStack(
+			tui.ListMap(selected, files, func(file fs.FileInfo) *tui.View {
+				return tui.HStack(tui.String(file.Name()).AbsoluteSize(20, 1), tui.Fmt("%d", file.Size()))
+			}).Border(),
+			keymap.HelpView(keys).AbsoluteSize(0, 1),
+		).Padding(1, 2),
+		tui.If(isHelpShown, keymap.FullHelpView(keys).Title("KEYS").Border().Padding(1, 2), nil),
+	).KeySequenceHandler(keys.Handler(map[string]func() any{
+		"up":     func() any { *selected--; return true },
+		"down":   func() any { *selected++; return true },
+		"top":    func() any { *selected = 0; return true },
+		"bottom": func() any { *selected = len(files) - 1; return true },
+		"select": func() any { return tui.TerminateWith(files[*selected].Name()) },
+		"help":   func() any { setHelpShown(!isHelpShown); return true },
+	}))
 }
 
 func renderBlocks() *tui.View {
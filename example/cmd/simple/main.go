@@ -12,7 +12,6 @@ func main() {
 				tui.VStack(tui.String("Hi!")).
 					Title("MESSAGE").
 					Border(tui.BorderOptionFGColor(color.RGB(200, 100, 200))),
-				tui.Spacer().AbsoluteSize(1, 0),
 				tui.InlineStack(
 					tui.String(" Red ").BGColor(color.RGB(100, 0, 0)),
 					tui.String(" Green ").BGColor(color.RGB(0, 100, 0)),
@@ -20,7 +19,7 @@ func main() {
 				).
 					Title("BACKGROUND COLOR").
 					Border(tui.BorderOptionFGColor(color.RGB(200, 100, 200))),
-			),
+			).Spacing(1),
 			tui.HStack(
 				tui.String("Red").FGColor(color.RGB(255, 0, 0)),
 				tui.String("Green").FGColor(color.RGB(0, 255, 0)),
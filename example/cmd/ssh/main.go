@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"flag"
+	"log"
+	"net"
+	"os"
+
+	"github.com/dytlzl/tervi/pkg/color"
+	"github.com/dytlzl/tervi/pkg/component"
+	"github.com/dytlzl/tervi/pkg/tui"
+	"github.com/dytlzl/tervi/pkg/tuissh"
+	"golang.org/x/crypto/ssh"
+)
+
+// Try it with `ssh -p 2222 localhost`.
+func main() {
+	addr := flag.String("addr", "localhost:2222", "address to listen on")
+	hostKey := flag.String("host-key", "", "path to the private host key (a new key is generated if empty)")
+	flag.Parse()
+
+	signer, err := loadHostKey(*hostKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// This example accepts any client. Set PasswordCallback or PublicKeyCallback for real use.
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("listening on %s", l.Addr())
+	err = tuissh.Serve(l, config, tuissh.RunView(func() *tui.View {
+		// The states of hooks are kept per session.
+		input := tui.UseRef("")
+		position := tui.UseRef(0)
+		return component.TextField(input, position).
+			Border(tui.BorderOptionFGColor(color.RGB(100, 100, 100))).
+			Title("Note (Ctrl+C to quit)").
+			RelativeSize(9, 9)
+	}))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadHostKey(path string) (ssh.Signer, error) {
+	if path == "" {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.NewSignerFromKey(key)
+	}
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(pem)
+}
@@ -14,7 +14,7 @@ func main() {
 				tui.String(dograMagra4).Strikethrough(),
 			).RelativeSize(10, 10).Title("ドグラマグラ - 夢野久作").Border(),
 		)
-	})
+	}, tui.OptionMouse())
 	if err != nil {
 		panic(err)
 	}
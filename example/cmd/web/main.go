@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/dytlzl/tervi/pkg/color"
+	"github.com/dytlzl/tervi/pkg/component"
+	"github.com/dytlzl/tervi/pkg/tui"
+	"github.com/dytlzl/tervi/pkg/tuiweb"
+)
+
+// Open http://localhost:8080 in a browser.
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	flag.Parse()
+
+	http.Handle("/", tuiweb.Handler(func() *tui.View {
+		// The states of hooks are kept per connection.
+		input := tui.UseRef("")
+		position := tui.UseRef(0)
+		return component.TextField(input, position).
+			Border(tui.BorderOptionFGColor(color.RGB(100, 100, 100))).
+			Title("Note").
+			RelativeSize(9, 9)
+	}, tui.OptionMouse()))
+	log.Printf("listening on http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
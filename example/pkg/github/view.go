@@ -58,10 +58,10 @@ func (m *SearchView) Body() *tui.View {
 		width, _, _ := tui.TermSize()
 		for i, item := range m.Result.Items {
 			if item.Origin != lastOrigin {
-				slice = append(slice, tui.String(" "+item.Origin+":\n").FGColor(8))
+				slice = append(slice, tui.String(" "+item.Origin+":\n").FGColor(color.Palette(8)))
 				lastOrigin = item.Origin
 			}
-			slice = append(slice, tui.Fmt("%s  #%d ", tui.If(i == m.selectedItem, ">", " "), i).FGColor(8))
+			slice = append(slice, tui.Fmt("%s  #%d ", tui.If(i == m.selectedItem, ">", " "), i).FGColor(color.Palette(8)))
 			if m.Type == "repo" {
 				repo := item.ResultItem.(Repository)
 				slice = append(slice, tui.Fmt("%s", repo.FullName).If(i == m.selectedItem, (*tui.View).Underline))
@@ -78,7 +78,7 @@ func (m *SearchView) Body() *tui.View {
 					path += "..."
 				}
 				slice = append(slice,
-					tui.String(item.Repository.FullName).FGColor(225).If(i == m.selectedItem, (*tui.View).Underline),
+					tui.String(item.Repository.FullName).FGColor(color.Palette(225)).If(i == m.selectedItem, (*tui.View).Underline),
 					tui.Fmt(" %s", path).If(i == m.selectedItem, (*tui.View).Underline),
 				)
 			}
@@ -177,14 +177,14 @@ func (m *SearchView) SubView() *tui.View {
 			return tui.InlineStack(
 				tui.If(repo.Description != "",
 					tui.InlineStack(
-						tui.String("Description: \n ").FGColor(8),
+						tui.String("Description: \n ").FGColor(color.Palette(8)),
 						tui.String(repo.Description+"\n\n"),
 					),
 					nil,
 				),
 				tui.If(m.ContentMap[repo.HtmlUrl] != "",
 					tui.InlineStack(
-						tui.String("README: \n ").FGColor(8),
+						tui.String("README: \n ").FGColor(color.Palette(8)),
 						tui.String(m.ContentMap[repo.HtmlUrl]+"\n"),
 					),
 					nil,
@@ -197,7 +197,7 @@ func (m *SearchView) SubView() *tui.View {
 				m.ContentRequestMap[item.Url] = true
 			}
 			if m.ContentMap[item.Url] == "" {
-				return tui.String("Loading...").FGColor(8)
+				return tui.String("Loading...").FGColor(color.Palette(8))
 			}
 			content := strings.ReplaceAll(m.ContentMap[item.Url], string(rune(9)), "    ")
 			lines := strings.Split(content, "\n")
@@ -232,7 +232,7 @@ func (m *SearchView) SubView() *tui.View {
 			return tui.InlineMapN(endRow-beginRow+1, func(i int) *tui.View {
 				rowNumber := beginRow + i
 				return tui.InlineStack(
-					tui.Fmt(fmt.Sprintf("%%%dd ", lineNumberWidth), rowNumber+1).FGColor(135),
+					tui.Fmt(fmt.Sprintf("%%%dd ", lineNumberWidth), rowNumber+1).FGColor(color.Palette(135)),
 					codeLineView(lines[rowNumber], m.Result.Query),
 					tui.Break(),
 				)
@@ -249,7 +249,7 @@ func codeLineView(line, pattern string) *tui.View {
 	} else {
 		return tui.InlineStack(
 			tui.String(line[:index]),
-			tui.String(line[index:index+len(pattern)]).BGColor(135),
+			tui.String(line[index:index+len(pattern)]).BGColor(color.Palette(135)),
 			tui.String(line[index+len(pattern):]),
 		)
 	}
@@ -3,11 +3,15 @@ module github.com/dytlzl/tervi
 go 1.18
 
 require (
-	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
-	golang.org/x/term v0.0.0-20220411215600-e5f449aeb171
+	golang.org/x/sys v0.21.0
+	golang.org/x/term v0.21.0
 )
 
 require (
 	github.com/mattn/go-runewidth v0.0.13
 	github.com/rivo/uniseg v0.2.0 // indirect
 )
+
+require golang.org/x/crypto v0.24.0
+
+require github.com/gorilla/websocket v1.5.3
@@ -1,9 +1,123 @@
 package color
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
 
-func RGB(red, green, blue int) uint8 {
-	return uint8(16 + 36*valueToIndex(red) + 6*valueToIndex(green) + valueToIndex(blue))
+// Color is a terminal color.
+// It holds the default color of the terminal, one of the 16 ANSI colors,
+// one of the 256 palette colors or a 24-bit RGB color.
+// The zero value is the default color.
+type Color struct {
+	kind  Kind
+	index uint8
+	red   uint8
+	green uint8
+	blue  uint8
+}
+
+// Kind is the kind of a Color.
+type Kind uint8
+
+const (
+	KindDefault Kind = iota
+	KindANSI
+	KindPalette
+	KindRGB
+)
+
+// Default is the default color of the terminal.
+var Default = Color{}
+
+// The 16 ANSI colors. The actual colors depend on the theme of the terminal.
+var (
+	Black         = ANSI(0)
+	Red           = ANSI(1)
+	Green         = ANSI(2)
+	Yellow        = ANSI(3)
+	Blue          = ANSI(4)
+	Magenta       = ANSI(5)
+	Cyan          = ANSI(6)
+	White         = ANSI(7)
+	BrightBlack   = ANSI(8)
+	BrightRed     = ANSI(9)
+	BrightGreen   = ANSI(10)
+	BrightYellow  = ANSI(11)
+	BrightBlue    = ANSI(12)
+	BrightMagenta = ANSI(13)
+	BrightCyan    = ANSI(14)
+	BrightWhite   = ANSI(15)
+)
+
+// ANSI returns one of the 16 ANSI colors. index must be less than 16.
+func ANSI(index uint8) Color {
+	return Color{kind: KindANSI, index: index & 0xf}
+}
+
+// Palette returns one of the 256 palette colors.
+func Palette(index uint8) Color {
+	return Color{kind: KindPalette, index: index}
+}
+
+// RGB returns a 24-bit color. Each value is clamped to the range from 0 to 255.
+func RGB(red, green, blue int) Color {
+	return Color{kind: KindRGB, red: clamp(red), green: clamp(green), blue: clamp(blue)}
+}
+
+// Hex parses a color written as "#rrggbb" or "#rgb".
+func Hex(s string) (Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return Default, fmt.Errorf("invalid hex color: %q", s)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Default, fmt.Errorf("invalid hex color: %q", s)
+	}
+	return RGB(int(value>>16&0xff), int(value>>8&0xff), int(value&0xff)), nil
+}
+
+// Cube returns the nearest color in the 6x6x6 color cube of the 256 palette colors.
+func Cube(red, green, blue int) Color {
+	return Palette(uint8(16 + 36*valueToIndex(red) + 6*valueToIndex(green) + valueToIndex(blue)))
+}
+
+// Kind returns the kind of the color.
+func (c Color) Kind() Kind {
+	return c.kind
+}
+
+// IsDefault reports whether the color is the default color of the terminal.
+func (c Color) IsDefault() bool {
+	return c.kind == KindDefault
+}
+
+// Index returns the index of an ANSI or palette color.
+func (c Color) Index() uint8 {
+	return c.index
+}
+
+// Values returns the red, green and blue values of an RGB color.
+func (c Color) Values() (red, green, blue uint8) {
+	return c.red, c.green, c.blue
+}
+
+func (c Color) String() string {
+	switch c.kind {
+	case KindANSI:
+		return fmt.Sprintf("ansi(%d)", c.index)
+	case KindPalette:
+		return strconv.Itoa(int(c.index))
+	case KindRGB:
+		return fmt.Sprintf("#%02x%02x%02x", c.red, c.green, c.blue)
+	}
+	return "default"
 }
 
 func RelativeBrightness(red, green, blue int) float64 {
@@ -17,6 +131,17 @@ func relativeBrightnessComponent(value float64) float64 {
 	return math.Pow(((value + 0.055) / 1.055), 2.4)
 }
 
+func clamp(value int) uint8 {
+	switch {
+	case value < 0:
+		return 0
+	case value > 255:
+		return 255
+	default:
+		return uint8(value)
+	}
+}
+
 func valueToIndex(value int) int {
 	switch {
 	case value < 95/2:
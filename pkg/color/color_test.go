@@ -4,7 +4,7 @@ import (
 	"testing"
 )
 
-func TestRGB(t *testing.T) {
+func TestCube(t *testing.T) {
 	tests := []struct {
 		name  string
 		red   int
@@ -57,13 +57,63 @@ func TestRGB(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := RGB(tt.red, tt.green, tt.blue); got != tt.want {
-				t.Errorf("RGB() = %v, want %v", got, tt.want)
+			if got := Cube(tt.red, tt.green, tt.blue); got != Palette(tt.want) {
+				t.Errorf("Cube() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestHex(t *testing.T) {
+	tests := []struct {
+		name    string
+		hex     string
+		want    Color
+		wantErr bool
+	}{
+		{
+			name: "six digits",
+			hex:  "#1da1f2",
+			want: RGB(0x1d, 0xa1, 0xf2),
+		},
+		{
+			name: "three digits",
+			hex:  "#f80",
+			want: RGB(0xff, 0x88, 0x00),
+		},
+		{
+			name:    "invalid digits",
+			hex:     "#12345g",
+			wantErr: true,
+		},
+		{
+			name:    "invalid length",
+			hex:     "#1234",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Hex(tt.hex)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Hex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Hex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZeroValueIsDefault(t *testing.T) {
+	if !(Color{}).IsDefault() {
+		t.Errorf("the zero value is not the default color")
+	}
+	if Palette(0).IsDefault() {
+		t.Errorf("Palette(0) is the default color")
+	}
+}
+
 func TestRelativeBrightness(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -0,0 +1,131 @@
+package color
+
+// Profile is the set of colors that a terminal can show.
+type Profile uint8
+
+const (
+	// Monochrome shows only the default colors.
+	Monochrome Profile = iota
+	// ANSI16 shows the 16 ANSI colors.
+	ANSI16
+	// ANSI256 shows the 256 palette colors.
+	ANSI256
+	// TrueColor shows 24-bit RGB colors.
+	TrueColor
+)
+
+func (p Profile) String() string {
+	switch p {
+	case Monochrome:
+		return "monochrome"
+	case ANSI16:
+		return "16 colors"
+	case ANSI256:
+		return "256 colors"
+	case TrueColor:
+		return "truecolor"
+	}
+	return "unknown"
+}
+
+// Convert returns the color nearest to c that the profile can show.
+func (p Profile) Convert(c Color) Color {
+	switch {
+	case c.kind == KindDefault:
+		return c
+	case p == Monochrome:
+		return Default
+	case p == ANSI16:
+		switch c.kind {
+		case KindANSI:
+			return c
+		case KindPalette:
+			if c.index < 16 {
+				return ANSI(c.index)
+			}
+		}
+		r, g, b := c.rgb()
+		return nearestANSI(r, g, b)
+	case p == ANSI256:
+		if c.kind == KindRGB {
+			return nearestPalette(c.red, c.green, c.blue)
+		}
+	}
+	return c
+}
+
+// ansiValues is the RGB values of the 16 ANSI colors in xterm.
+var ansiValues = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeValues is the values of each axis of the 6x6x6 color cube.
+var cubeValues = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// rgb returns the RGB values of the color, as xterm shows it by default.
+func (c Color) rgb() (uint8, uint8, uint8) {
+	switch c.kind {
+	case KindANSI:
+		v := ansiValues[c.index]
+		return v[0], v[1], v[2]
+	case KindPalette:
+		switch {
+		case c.index < 16:
+			v := ansiValues[c.index]
+			return v[0], v[1], v[2]
+		case c.index < 232:
+			i := c.index - 16
+			return cubeValues[i/36], cubeValues[i/6%6], cubeValues[i%6]
+		default:
+			gray := 8 + 10*(c.index-232)
+			return gray, gray, gray
+		}
+	}
+	return c.red, c.green, c.blue
+}
+
+func nearestANSI(r, g, b uint8) Color {
+	nearest := 0
+	minDistance := -1
+	for i, v := range ansiValues {
+		d := distance(r, g, b, v[0], v[1], v[2])
+		if minDistance < 0 || d < minDistance {
+			nearest = i
+			minDistance = d
+		}
+	}
+	return ANSI(uint8(nearest))
+}
+
+// nearestPalette returns the nearer of the nearest color in the color cube and that in the grayscale ramp.
+func nearestPalette(r, g, b uint8) Color {
+	cube := Cube(int(r), int(g), int(b))
+	cr, cg, cb := cube.rgb()
+
+	average := (int(r) + int(g) + int(b)) / 3
+	grayIndex := 23
+	if average < 8 {
+		grayIndex = 0
+	} else if average < 238 {
+		grayIndex = (average - 3) / 10
+	}
+	gray := Palette(uint8(232 + grayIndex))
+	gr, gg, gb := gray.rgb()
+
+	if distance(r, g, b, gr, gg, gb) < distance(r, g, b, cr, cg, cb) {
+		return gray
+	}
+	return cube
+}
+
+// distance returns the squared distance between two colors weighted by the mean of the red values.
+func distance(r1, g1, b1, r2, g2, b2 uint8) int {
+	rMean := (int(r1) + int(r2)) / 2
+	dr := int(r1) - int(r2)
+	dg := int(g1) - int(g2)
+	db := int(b1) - int(b2)
+	return ((512+rMean)*dr*dr)>>8 + 4*dg*dg + ((767-rMean)*db*db)>>8
+}
@@ -0,0 +1,76 @@
+package color
+
+import (
+	"testing"
+)
+
+func TestProfile_Convert(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		color   Color
+		want    Color
+	}{
+		{
+			name:    "truecolor keeps RGB",
+			profile: TrueColor,
+			color:   RGB(29, 161, 242),
+			want:    RGB(29, 161, 242),
+		},
+		{
+			name:    "256 colors convert RGB into the color cube",
+			profile: ANSI256,
+			color:   RGB(255, 0, 0),
+			want:    Palette(196),
+		},
+		{
+			name:    "256 colors convert gray RGB into the grayscale ramp",
+			profile: ANSI256,
+			color:   RGB(100, 100, 100),
+			want:    Palette(241),
+		},
+		{
+			name:    "256 colors keep ANSI",
+			profile: ANSI256,
+			color:   Red,
+			want:    Red,
+		},
+		{
+			name:    "16 colors convert RGB into ANSI",
+			profile: ANSI16,
+			color:   RGB(250, 10, 10),
+			want:    BrightRed,
+		},
+		{
+			name:    "16 colors convert low palette colors into ANSI",
+			profile: ANSI16,
+			color:   Palette(4),
+			want:    Blue,
+		},
+		{
+			name:    "16 colors convert the color cube into ANSI",
+			profile: ANSI16,
+			color:   Palette(46),
+			want:    BrightGreen,
+		},
+		{
+			name:    "monochrome drops colors",
+			profile: Monochrome,
+			color:   Palette(0),
+			want:    Default,
+		},
+		{
+			name:    "default is kept",
+			profile: ANSI16,
+			color:   Default,
+			want:    Default,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.Convert(tt.color); got != tt.want {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package component
 
 import (
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/dytlzl/tervi/pkg/key"
@@ -37,6 +38,10 @@ func TextInput(input *string, position *int, onChanged func()) *tui.View {
 				_, size := utf8.DecodeRuneInString((*input)[*position:])
 				*position += size
 			}
+		case key.Home:
+			*position = 0
+		case key.End:
+			*position = len(*input)
 		case key.ArrowUp, key.ArrowDown:
 			return nil
 		case key.Del:
@@ -45,12 +50,36 @@ func TextInput(input *string, position *int, onChanged func()) *tui.View {
 				*input = (*input)[:*position-size] + (*input)[*position:]
 				*position -= size
 			}
+		case key.Delete:
+			if *position < len(*input) {
+				_, size := utf8.DecodeRuneInString((*input)[*position:])
+				*input = (*input)[:*position] + (*input)[*position+size:]
+			}
 		default:
+			if r > unicode.MaxRune {
+				// special keys and keys with modifiers
+				return nil
+			}
 			*input = (*input)[:*position] + string(r) + (*input)[*position:]
 			*position += utf8.RuneLen(r)
 		}
 		onChanged()
 		return true
+	}).OnPaste(func(text string) any {
+		// Line breaks are replaced with spaces and the other control characters are removed.
+		text = strings.Map(func(r rune) rune {
+			switch {
+			case r == '\n':
+				return ' '
+			case unicode.IsControl(r):
+				return -1
+			}
+			return r
+		}, text)
+		*input = (*input)[:*position] + text + (*input)[*position:]
+		*position += len(text)
+		onChanged()
+		return true
 	})
 }
 
@@ -101,11 +130,31 @@ func TextField(input *string, position *int) *tui.View {
 				*input = (*input)[:*position-size] + (*input)[*position:]
 				*position -= size
 			}
+		case key.Delete:
+			if *position < len(*input) {
+				_, size := utf8.DecodeRuneInString((*input)[*position:])
+				*input = (*input)[:*position] + (*input)[*position+size:]
+			}
 		default:
+			if r > unicode.MaxRune {
+				// special keys and keys with modifiers
+				return nil
+			}
 			*input = (*input)[:*position] + string(r) + (*input)[*position:]
 			*position += utf8.RuneLen(r)
 		}
 		return true
+	}).OnPaste(func(text string) any {
+		// Line breaks and tabs are kept and the other control characters are removed.
+		text = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\n' && r != '\t' {
+				return -1
+			}
+			return r
+		}, text)
+		*input = (*input)[:*position] + text + (*input)[*position:]
+		*position += len(text)
+		return true
 	})
 }
 
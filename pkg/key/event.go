@@ -0,0 +1,219 @@
+package key
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Modifier is a set of modifier keys held with a key.
+type Modifier uint8
+
+const (
+	Shift Modifier = 1 << iota
+	Alt
+	Ctrl
+)
+
+// Action is what happened to a key.
+type Action uint8
+
+const (
+	Press Action = iota
+	Repeat
+	Release
+)
+
+// Event is a key event.
+// Keys are normalized so that the same key has the same event regardless of how the terminal encodes it:
+// Ctrl with a letter has the lowercase letter as the code, Shift with a letter alone is the uppercase letter,
+// and BackTab is Tab with Shift.
+type Event struct {
+	// Code is the key, a character such as 'a' or one of the codes of this package such as ArrowUp.
+	Code rune
+	// Rune is the character typed by the key, or 0 if the key types no character.
+	Rune   rune
+	Mod    Modifier
+	Action Action
+}
+
+// FromRune converts a key in the rune encoding of this package, which combines the code
+// with ModShift, ModAlt and ModCtrl, into an Event.
+func FromRune(r rune) Event {
+	e := Event{Code: r &^ (ModShift | ModAlt | ModCtrl)}
+	if r&ModShift != 0 {
+		e.Mod |= Shift
+	}
+	if r&ModAlt != 0 {
+		e.Mod |= Alt
+	}
+	if r&ModCtrl != 0 {
+		e.Mod |= Ctrl
+	}
+	return e.normalize()
+}
+
+func (e Event) normalize() Event {
+	switch {
+	case e.Code == BackTab:
+		e.Code = Tab
+		e.Mod |= Shift
+	case e.Code == Null:
+		e.Code = ' '
+		e.Mod |= Ctrl
+	case e.Code >= CtrlA && e.Code <= CtrlZ && e.Code != Tab && e.Code != Enter:
+		e.Code += 'a' - CtrlA
+		e.Mod |= Ctrl
+	case e.Code >= 'A' && e.Code <= 'Z' && e.Mod&Ctrl != 0:
+		e.Code += 'a' - 'A'
+		e.Mod |= Shift
+	case e.Code >= 'a' && e.Code <= 'z' && e.Mod&Shift != 0 && e.Mod&Ctrl == 0:
+		e.Code -= 'a' - 'A'
+		e.Mod &^= Shift
+	}
+	e.Rune = 0
+	if e.Mod&(Alt|Ctrl) == 0 && unicode.IsPrint(e.Code) {
+		e.Rune = e.Code
+	}
+	return e
+}
+
+// Legacy returns the key in the rune encoding of this package,
+// e.g. CtrlA for Ctrl+A and ArrowUp|ModShift for Shift+ArrowUp.
+// The action is dropped. Some keys have several encodings, and Legacy returns the canonical one
+// that FromRune reads back as the same event. For example, 'A'|ModCtrl and CtrlA|ModShift are both
+// Ctrl+Shift+A, and Legacy returns CtrlA|ModShift.
+func (e Event) Legacy() rune {
+	code, mod := e.Code, e.Mod
+	switch {
+	case code == Tab && mod&Shift != 0:
+		code = BackTab
+		mod &^= Shift
+	case code == ' ' && mod&Ctrl != 0:
+		code = Null
+		mod &^= Ctrl
+	case code >= 'a' && code <= 'z' && mod&Ctrl != 0:
+		code += CtrlA - 'a'
+		mod &^= Ctrl
+	}
+	if mod&Shift != 0 {
+		code |= ModShift
+	}
+	if mod&Alt != 0 {
+		code |= ModAlt
+	}
+	if mod&Ctrl != 0 {
+		code |= ModCtrl
+	}
+	return code
+}
+
+// Is reports whether the event is a press or a repeat of the key described as in Parse.
+// It reports false if the description is invalid.
+func (e Event) Is(s string) bool {
+	want, err := Parse(s)
+	if err != nil {
+		return false
+	}
+	return e.Action != Release && e.Code == want.Code && e.Mod == want.Mod
+}
+
+var names = map[rune]string{
+	Enter:      "enter",
+	Tab:        "tab",
+	Esc:        "esc",
+	Del:        "backspace",
+	' ':        "space",
+	ArrowUp:    "up",
+	ArrowDown:  "down",
+	ArrowRight: "right",
+	ArrowLeft:  "left",
+	Home:       "home",
+	End:        "end",
+	Insert:     "insert",
+	Delete:     "delete",
+	PageUp:     "pgup",
+	PageDown:   "pgdown",
+	F1:         "f1",
+	F2:         "f2",
+	F3:         "f3",
+	F4:         "f4",
+	F5:         "f5",
+	F6:         "f6",
+	F7:         "f7",
+	F8:         "f8",
+	F9:         "f9",
+	F10:        "f10",
+	F11:        "f11",
+	F12:        "f12",
+	Unknown:    "unknown",
+}
+
+var aliases = map[string]rune{
+	"return":   Enter,
+	"escape":   Esc,
+	"pageup":   PageUp,
+	"pagedown": PageDown,
+}
+
+// String returns the key with its modifiers, such as "ctrl+shift+p", "alt+enter" or "A".
+// The result can be read by Parse.
+func (e Event) String() string {
+	var b strings.Builder
+	if e.Mod&Ctrl != 0 {
+		b.WriteString("ctrl+")
+	}
+	if e.Mod&Alt != 0 {
+		b.WriteString("alt+")
+	}
+	if e.Mod&Shift != 0 {
+		b.WriteString("shift+")
+	}
+	if name, ok := names[e.Code]; ok {
+		b.WriteString(name)
+	} else {
+		b.WriteRune(e.Code)
+	}
+	return b.String()
+}
+
+// Parse reads a key described as modifiers and a key joined by "+", such as "ctrl+shift+p" or "alt+enter".
+// The modifiers are "ctrl", "alt" and "shift", and the key is a character or a name such as
+// "enter", "tab", "space", "up", "pgdown" or "f1". Both are case-insensitive except a single character.
+func Parse(s string) (Event, error) {
+	parts := strings.Split(s, "+")
+	name, mods := parts[len(parts)-1], parts[:len(parts)-1]
+	if name == "" && len(parts) >= 2 {
+		// The key is "+" itself.
+		name, mods = "+", parts[:len(parts)-2]
+	}
+	var e Event
+	for _, m := range mods {
+		switch strings.ToLower(m) {
+		case "ctrl", "control":
+			e.Mod |= Ctrl
+		case "alt", "option", "meta":
+			e.Mod |= Alt
+		case "shift":
+			e.Mod |= Shift
+		default:
+			return Event{}, fmt.Errorf("unknown modifier %q in %q", m, s)
+		}
+	}
+	if r := []rune(name); len(r) == 1 {
+		e.Code = r[0]
+		return e.normalize(), nil
+	}
+	lower := strings.ToLower(name)
+	if code, ok := aliases[lower]; ok {
+		e.Code = code
+		return e.normalize(), nil
+	}
+	for code, n := range names {
+		if n == lower {
+			e.Code = code
+			return e.normalize(), nil
+		}
+	}
+	return Event{}, fmt.Errorf("unknown key %q in %q", name, s)
+}
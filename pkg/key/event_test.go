@@ -0,0 +1,116 @@
+package key
+
+import "testing"
+
+func TestFromRune(t *testing.T) {
+	tests := []struct {
+		name string
+		r    rune
+		want Event
+	}{
+		{"character", 'a', Event{Code: 'a', Rune: 'a'}},
+		{"uppercase", 'A', Event{Code: 'A', Rune: 'A'}},
+		{"control character", CtrlP, Event{Code: 'p', Mod: Ctrl}},
+		{"enter", Enter, Event{Code: Enter}},
+		{"tab", Tab, Event{Code: Tab}},
+		{"back tab", BackTab, Event{Code: Tab, Mod: Shift}},
+		{"ctrl+space", Null, Event{Code: ' ', Mod: Ctrl}},
+		{"alt", 'x' | ModAlt, Event{Code: 'x', Mod: Alt}},
+		{"shift and letter", 'a' | ModShift, Event{Code: 'A', Rune: 'A'}},
+		{"ctrl and shifted letter", 'A' | ModCtrl, Event{Code: 'a', Mod: Ctrl | Shift}},
+		{"modified arrow", ArrowLeft | ModCtrl, Event{Code: ArrowLeft, Mod: Ctrl}},
+		{"alt and control character", CtrlA | ModAlt, Event{Code: 'a', Mod: Ctrl | Alt}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromRune(tt.r); got != tt.want {
+				t.Errorf("FromRune() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvent_Legacy(t *testing.T) {
+	for _, r := range []rune{'a', 'A', CtrlP, Enter, Tab, BackTab, Null, Esc, Del, 'x' | ModAlt, ArrowLeft | ModCtrl | ModShift, F5 | ModAlt} {
+		if got := FromRune(r).Legacy(); got != r {
+			t.Errorf("FromRune(%#x).Legacy() = %#x", r, got)
+		}
+	}
+	// Other encodings of the same keys are returned in the canonical encoding.
+	for r, want := range map[rune]rune{
+		'A' | ModCtrl:  CtrlA | ModShift,
+		'a' | ModShift: 'A',
+		'a' | ModCtrl:  CtrlA,
+		Tab | ModShift: BackTab,
+		' ' | ModCtrl:  Null,
+	} {
+		e := FromRune(r)
+		if got := e.Legacy(); got != want {
+			t.Errorf("FromRune(%#x).Legacy() = %#x, want %#x", r, got, want)
+		}
+		if got := FromRune(e.Legacy()); got != e {
+			t.Errorf("FromRune(%#x) = %+v, want %+v", e.Legacy(), got, e)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Event
+		wantStr string
+	}{
+		{"ctrl+shift+p", Event{Code: 'p', Mod: Ctrl | Shift}, "ctrl+shift+p"},
+		{"Ctrl+P", Event{Code: 'p', Mod: Ctrl | Shift}, "ctrl+shift+p"},
+		{"ctrl+p", Event{Code: 'p', Mod: Ctrl}, "ctrl+p"},
+		{"alt+enter", Event{Code: Enter, Mod: Alt}, "alt+enter"},
+		{"shift+a", Event{Code: 'A', Rune: 'A'}, "A"},
+		{"shift+tab", Event{Code: Tab, Mod: Shift}, "shift+tab"},
+		{"space", Event{Code: ' ', Rune: ' '}, "space"},
+		{"PageDown", Event{Code: PageDown}, "pgdown"},
+		{"ctrl+up", Event{Code: ArrowUp, Mod: Ctrl}, "ctrl+up"},
+		{"f12", Event{Code: F12}, "f12"},
+		{"+", Event{Code: '+', Rune: '+'}, "+"},
+		{"alt++", Event{Code: '+', Mod: Alt}, "alt++"},
+		{"é", Event{Code: 'é', Rune: 'é'}, "é"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := Parse(tt.s)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			if s := got.String(); s != tt.wantStr {
+				t.Errorf("String() = %q, want %q", s, tt.wantStr)
+			}
+		})
+	}
+}
+
+func TestParse_error(t *testing.T) {
+	for _, s := range []string{"", "hyper+a", "ctrl+nokey"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) returned no error", s)
+		}
+	}
+}
+
+func TestEvent_Is(t *testing.T) {
+	e := FromRune(CtrlS)
+	if !e.Is("ctrl+s") {
+		t.Errorf("%v is not ctrl+s", e)
+	}
+	if e.Is("s") {
+		t.Errorf("%v is s", e)
+	}
+	if e.Is("ctrl+nope") {
+		t.Errorf("%v is an invalid key", e)
+	}
+	e.Action = Release
+	if e.Is("ctrl+s") {
+		t.Errorf("the release of %v is ctrl+s", e)
+	}
+}
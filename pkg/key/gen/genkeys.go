@@ -29,6 +29,7 @@ func main() {
 
 	codes := []Code{
 		{name: "Null", value: 0},
+		{name: "Tab", value: 9},
 		{name: "Enter", value: 13},
 		{name: "Esc", value: 27},
 		{name: "Del", value: 127},
@@ -36,6 +37,33 @@ func main() {
 		{name: "ArrowDown", value: 0x1b5b00 + 'B'},
 		{name: "ArrowRight", value: 0x1b5b00 + 'C'},
 		{name: "ArrowLeft", value: 0x1b5b00 + 'D'},
+		{name: "End", value: 0x1b5b00 + 'F'},
+		{name: "Home", value: 0x1b5b00 + 'H'},
+		{name: "BackTab", value: 0x1b5b00 + 'Z'},
+		// Keys sent as ESC O P-S
+		{name: "F1", value: 0x1b4f00 + 'P'},
+		{name: "F2", value: 0x1b4f00 + 'Q'},
+		{name: "F3", value: 0x1b4f00 + 'R'},
+		{name: "F4", value: 0x1b4f00 + 'S'},
+		// Keys sent as ESC [ n ~
+		{name: "Insert", value: 0x1b7e00 + 2},
+		{name: "Delete", value: 0x1b7e00 + 3},
+		{name: "PageUp", value: 0x1b7e00 + 5},
+		{name: "PageDown", value: 0x1b7e00 + 6},
+		{name: "F5", value: 0x1b7e00 + 15},
+		{name: "F6", value: 0x1b7e00 + 17},
+		{name: "F7", value: 0x1b7e00 + 18},
+		{name: "F8", value: 0x1b7e00 + 19},
+		{name: "F9", value: 0x1b7e00 + 20},
+		{name: "F10", value: 0x1b7e00 + 21},
+		{name: "F11", value: 0x1b7e00 + 23},
+		{name: "F12", value: 0x1b7e00 + 24},
+		// Escape sequences that are not recognized
+		{name: "Unknown", value: 0x1b0000},
+		// Modifiers combined with the codes above by bitwise OR
+		{name: "ModShift", value: 1 << 24},
+		{name: "ModAlt", value: 1 << 25},
+		{name: "ModCtrl", value: 1 << 26},
 	}
 
 	for i := 0; i < 26; i++ {
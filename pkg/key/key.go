@@ -12,6 +12,7 @@ const (
 	CtrlF      rune = 6
 	CtrlG      rune = 7
 	CtrlH      rune = 8
+	Tab        rune = 9
 	CtrlI      rune = 9
 	CtrlJ      rune = 10
 	CtrlK      rune = 11
@@ -33,8 +34,31 @@ const (
 	CtrlZ      rune = 26
 	Esc        rune = 27
 	Del        rune = 127
+	Unknown    rune = 1769472
+	F1         rune = 1789776
+	F2         rune = 1789777
+	F3         rune = 1789778
+	F4         rune = 1789779
 	ArrowUp    rune = 1792833
 	ArrowDown  rune = 1792834
 	ArrowRight rune = 1792835
 	ArrowLeft  rune = 1792836
+	End        rune = 1792838
+	Home       rune = 1792840
+	BackTab    rune = 1792858
+	Insert     rune = 1801730
+	Delete     rune = 1801731
+	PageUp     rune = 1801733
+	PageDown   rune = 1801734
+	F5         rune = 1801743
+	F6         rune = 1801745
+	F7         rune = 1801746
+	F8         rune = 1801747
+	F9         rune = 1801748
+	F10        rune = 1801749
+	F11        rune = 1801751
+	F12        rune = 1801752
+	ModShift   rune = 16777216
+	ModAlt     rune = 33554432
+	ModCtrl    rune = 67108864
 )
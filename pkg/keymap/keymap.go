@@ -0,0 +1,317 @@
+// Package keymap binds keys and sequences of keys to named actions, so that views handle actions
+// instead of keys, users can remap the keys in a config file, and the bindings can be listed in a help view.
+package keymap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dytlzl/tervi/pkg/key"
+	"github.com/dytlzl/tervi/pkg/tui"
+	"github.com/mattn/go-runewidth"
+)
+
+// Binding binds keys to an action.
+type Binding struct {
+	// Action names the action, e.g. "up".
+	Action string
+	// Keys are the keys described as in key.Parse, e.g. "k" or "ctrl+p",
+	// or sequences of them separated by spaces, e.g. "g g", "ctrl+x ctrl+s" or "<leader> f s".
+	Keys []string
+	// Help describes the action in help views. Bindings without help are not listed.
+	Help string
+}
+
+// Keymap is an ordered set of bindings.
+// A keymap is scoped to a view by passing its Handler to (*tui.View).KeySequenceHandler,
+// or to the whole app by passing it to the handler of the root view.
+type Keymap struct {
+	bindings []binding
+	leader   string
+}
+
+type binding struct {
+	Binding
+	sequences [][]key.Event
+}
+
+// DefaultLeader is the key that <leader> in sequences stands for unless SetLeader changes it.
+const DefaultLeader = "\\"
+
+// New creates a keymap of the bindings.
+// It returns an error if a key is invalid or an action is bound twice.
+func New(bindings ...Binding) (*Keymap, error) {
+	m := &Keymap{bindings: make([]binding, 0, len(bindings)), leader: DefaultLeader}
+	for _, b := range bindings {
+		if _, ok := m.find(b.Action); ok {
+			return nil, fmt.Errorf("action %q is bound twice", b.Action)
+		}
+		sequences, err := parseKeys(b.Keys, m.leader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bind action %q: %w", b.Action, err)
+		}
+		b.Keys = append([]string(nil), b.Keys...)
+		m.bindings = append(m.bindings, binding{b, sequences})
+	}
+	return m, nil
+}
+
+// MustNew is like New but panics if the bindings are invalid.
+func MustNew(bindings ...Binding) *Keymap {
+	m, err := New(bindings...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func parseKeys(keys []string, leader string) ([][]key.Event, error) {
+	sequences := make([][]key.Event, 0, len(keys))
+	for _, k := range keys {
+		fields := strings.Fields(k)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("empty key %q", k)
+		}
+		sequence := make([]key.Event, 0, len(fields))
+		for _, f := range fields {
+			if f == "<leader>" {
+				f = leader
+			}
+			e, err := key.Parse(f)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, e)
+		}
+		sequences = append(sequences, sequence)
+	}
+	return sequences, nil
+}
+
+// SetLeader sets the key that <leader> in sequences stands for, e.g. "space".
+func (m *Keymap) SetLeader(leader string) error {
+	_, err := key.Parse(leader)
+	if err != nil {
+		return fmt.Errorf("invalid leader: %w", err)
+	}
+	m.leader = leader
+	for i, b := range m.bindings {
+		// The keys were valid with the old leader, and are valid with the new one too.
+		m.bindings[i].sequences, _ = parseKeys(b.Keys, leader)
+	}
+	return nil
+}
+
+func (m *Keymap) find(action string) (int, bool) {
+	for i, b := range m.bindings {
+		if b.Action == action {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Match returns the action bound to the key events, a single key or a sequence of keys.
+// Releases of keys match no action.
+func (m *Keymap) Match(keys ...key.Event) (string, bool) {
+	for _, b := range m.bindings {
+		for _, sequence := range b.sequences {
+			if len(sequence) == len(keys) && hasPrefix(sequence, keys) {
+				return b.Action, true
+			}
+		}
+	}
+	return "", false
+}
+
+// hasPrefix reports whether the sequence begins with the keys.
+func hasPrefix(sequence, keys []key.Event) bool {
+	if len(keys) > len(sequence) {
+		return false
+	}
+	for i, e := range keys {
+		if e.Action == key.Release || e.Code != sequence[i].Code || e.Mod != sequence[i].Mod {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys returns the keys bound to the action.
+func (m *Keymap) Keys(action string) []string {
+	i, ok := m.find(action)
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), m.bindings[i].Keys...)
+}
+
+// Bindings returns the bindings in order.
+func (m *Keymap) Bindings() []Binding {
+	bindings := make([]Binding, 0, len(m.bindings))
+	for _, b := range m.bindings {
+		b.Keys = append([]string(nil), b.Keys...)
+		bindings = append(bindings, b.Binding)
+	}
+	return bindings
+}
+
+// Rebind replaces the keys bound to the action. No keys unbind the action.
+func (m *Keymap) Rebind(action string, keys ...string) error {
+	i, ok := m.find(action)
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	sequences, err := parseKeys(keys, m.leader)
+	if err != nil {
+		return fmt.Errorf("failed to bind action %q: %w", action, err)
+	}
+	m.bindings[i].Keys = append([]string(nil), keys...)
+	m.bindings[i].sequences = sequences
+	return nil
+}
+
+// Load reads overrides of the bindings as a JSON object mapping actions to keys,
+// such as {"up": ["k", "up"], "quit": ["ctrl+q"]}, and rebinds the actions.
+// Nothing is rebound if an action is unknown or a key is invalid.
+func (m *Keymap) Load(r io.Reader) error {
+	var overrides map[string][]string
+	err := json.NewDecoder(r).Decode(&overrides)
+	if err != nil {
+		return fmt.Errorf("failed to decode keymap: %w", err)
+	}
+	for action, keys := range overrides {
+		if _, ok := m.find(action); !ok {
+			return fmt.Errorf("unknown action %q", action)
+		}
+		_, err := parseKeys(keys, m.leader)
+		if err != nil {
+			return fmt.Errorf("failed to bind action %q: %w", action, err)
+		}
+	}
+	for action, keys := range overrides {
+		_ = m.Rebind(action, keys...)
+	}
+	return nil
+}
+
+// LoadFile reads overrides of the bindings from the file as in Load.
+// If the file does not exist, the error satisfies errors.Is(err, fs.ErrNotExist).
+func (m *Keymap) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = m.Load(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Handler returns a handler of sequences of keys that calls the handler of the action bound to the keys.
+// It returns tui.KeyPending for the beginning of a longer sequence of an action with a handler,
+// so a key that begins a sequence, e.g. "g" of "g g", should not be bound to another action.
+// Keys of no action or of an action without a handler are passed on to the other handlers.
+func (m *Keymap) Handler(handlers map[string]func() any) func(keys []key.Event) any {
+	return func(keys []key.Event) any {
+		var matched func() any
+		for _, b := range m.bindings {
+			fn, ok := handlers[b.Action]
+			if !ok {
+				continue
+			}
+			for _, sequence := range b.sequences {
+				if !hasPrefix(sequence, keys) {
+					continue
+				}
+				if len(sequence) > len(keys) {
+					return tui.KeyPending
+				}
+				if matched == nil {
+					matched = fn
+				}
+			}
+		}
+		if matched == nil {
+			return nil
+		}
+		return matched()
+	}
+}
+
+type entry struct {
+	keys string
+	help string
+}
+
+// entries returns the bindings with help that continue the pending keys,
+// with the keys that follow the pending keys.
+func entries(maps []*Keymap, pending []key.Event) []entry {
+	var entries []entry
+	for _, m := range maps {
+		if m == nil {
+			continue
+		}
+		for _, b := range m.bindings {
+			if b.Help == "" {
+				continue
+			}
+			names := make([]string, 0, len(b.sequences))
+			for _, sequence := range b.sequences {
+				if len(sequence) > len(pending) && hasPrefix(sequence, pending) {
+					names = append(names, sequenceString(sequence[len(pending):]))
+				}
+			}
+			if len(names) > 0 {
+				entries = append(entries, entry{strings.Join(names, "/"), b.Help})
+			}
+		}
+	}
+	return entries
+}
+
+func sequenceString(keys []key.Event) string {
+	names := make([]string, 0, len(keys))
+	for _, e := range keys {
+		names = append(names, e.String())
+	}
+	return strings.Join(names, " ")
+}
+
+// HelpView returns a line listing the bound keys of the keymaps with help, such as "k/up up • q quit",
+// to be shown as a footer. While keys of a sequence are pending, it lists the keys that can follow them,
+// such as "g: g top • e bottom". It should be called while the program is rendering its views.
+func HelpView(maps ...*Keymap) *tui.View {
+	pending := tui.PendingKeys()
+	entries := entries(maps, pending)
+	items := make([]string, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, e.keys+" "+e.help)
+	}
+	line := strings.Join(items, " • ")
+	if len(pending) > 0 {
+		line = sequenceString(pending) + ": " + line
+	}
+	return tui.String(line)
+}
+
+// FullHelpView returns the bound keys of the keymaps with help, one binding per line
+// with the keys aligned in a column, to be shown e.g. in a bordered overlay.
+func FullHelpView(maps ...*Keymap) *tui.View {
+	entries := entries(maps, nil)
+	width := 0
+	for _, e := range entries {
+		width = tui.If(runewidth.StringWidth(e.keys) > width, runewidth.StringWidth(e.keys), width)
+	}
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		padding := strings.Repeat(" ", width-runewidth.StringWidth(e.keys))
+		lines = append(lines, e.keys+padding+"  "+e.help)
+	}
+	return tui.String(strings.Join(lines, "\n"))
+}
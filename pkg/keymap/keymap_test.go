@@ -0,0 +1,210 @@
+package keymap
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dytlzl/tervi/pkg/key"
+	"github.com/dytlzl/tervi/pkg/tui"
+)
+
+func newTestKeymap(t *testing.T) *Keymap {
+	t.Helper()
+	m, err := New(
+		Binding{Action: "up", Keys: []string{"up", "k"}, Help: "up"},
+		Binding{Action: "down", Keys: []string{"down", "j"}, Help: "down"},
+		Binding{Action: "hidden", Keys: []string{"ctrl+l"}},
+		Binding{Action: "quit", Keys: []string{"q", "ctrl+c"}, Help: "quit"},
+		Binding{Action: "top", Keys: []string{"g g"}, Help: "top"},
+		Binding{Action: "bottom", Keys: []string{"g e", "<leader> j"}, Help: "bottom"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Binding{Action: "up", Keys: []string{"ctrl+nope"}})
+	if err == nil {
+		t.Error("invalid key: expected an error")
+	}
+	_, err = New(Binding{Action: "up", Keys: []string{"k"}}, Binding{Action: "up", Keys: []string{"up"}})
+	if err == nil {
+		t.Error("duplicate action: expected an error")
+	}
+}
+
+func TestKeymap_Match(t *testing.T) {
+	m := newTestKeymap(t)
+	tests := []struct {
+		event  key.Event
+		action string
+		ok     bool
+	}{
+		{key.Event{Code: 'k'}, "up", true},
+		{key.Event{Code: key.ArrowUp}, "up", true},
+		{key.Event{Code: key.ArrowUp, Action: key.Repeat}, "up", true},
+		{key.Event{Code: key.ArrowUp, Action: key.Release}, "", false},
+		{key.FromRune(key.CtrlC), "quit", true},
+		{key.Event{Code: 'K'}, "", false},
+		{key.Event{Code: 'k', Mod: key.Alt}, "", false},
+		{key.Event{Code: 'g'}, "", false},
+	}
+	for _, tt := range tests {
+		action, ok := m.Match(tt.event)
+		if action != tt.action || ok != tt.ok {
+			t.Errorf("Match(%v) = %q, %v, want %q, %v", tt.event, action, ok, tt.action, tt.ok)
+		}
+	}
+}
+
+func TestKeymap_Match_sequence(t *testing.T) {
+	m := newTestKeymap(t)
+	g, e := key.Event{Code: 'g'}, key.Event{Code: 'e'}
+	if action, _ := m.Match(g, g); action != "top" {
+		t.Errorf("g g matches %q, want top", action)
+	}
+	if action, _ := m.Match(g, e); action != "bottom" {
+		t.Errorf("g e matches %q, want bottom", action)
+	}
+	if action, _ := m.Match(key.Event{Code: '\\'}, key.Event{Code: 'j'}); action != "bottom" {
+		t.Errorf("\\ j matches %q, want bottom", action)
+	}
+	err := m.SetLeader("space")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action, _ := m.Match(key.Event{Code: ' '}, key.Event{Code: 'j'}); action != "bottom" {
+		t.Errorf("space j matches %q, want bottom", action)
+	}
+	if err := m.SetLeader("ctrl+nope"); err == nil {
+		t.Error("invalid leader: expected an error")
+	}
+	if _, err := New(Binding{Action: "up", Keys: []string{" "}}); err == nil {
+		t.Error("empty key: expected an error")
+	}
+}
+
+func TestKeymap_Load(t *testing.T) {
+	m := newTestKeymap(t)
+	err := m.Load(strings.NewReader(`{"up": ["ctrl+p"], "down": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Keys("up"); !reflect.DeepEqual(got, []string{"ctrl+p"}) {
+		t.Errorf("Keys(up) = %q", got)
+	}
+	if _, ok := m.Match(key.Event{Code: 'j'}); ok {
+		t.Error("j is still bound after unbinding down")
+	}
+	if action, _ := m.Match(key.Event{Code: 'p', Mod: key.Ctrl}); action != "up" {
+		t.Errorf("ctrl+p matches %q, want up", action)
+	}
+
+	for _, input := range []string{`{"left": ["h"]}`, `{"quit": ["x", "ctrl+nope"]}`, `["q"]`} {
+		err := m.Load(strings.NewReader(input))
+		if err == nil {
+			t.Errorf("Load(%s): expected an error", input)
+		}
+	}
+	if got := m.Keys("quit"); !reflect.DeepEqual(got, []string{"q", "ctrl+c"}) {
+		t.Errorf("Keys(quit) = %q after failed loads", got)
+	}
+}
+
+func TestKeymap_LoadFile(t *testing.T) {
+	m := newTestKeymap(t)
+	path := filepath.Join(t.TempDir(), "keymap.json")
+	err := m.LoadFile(path)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: err = %v", err)
+	}
+	err = os.WriteFile(path, []byte(`{"quit": ["esc"]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action, _ := m.Match(key.Event{Code: key.Esc}); action != "quit" {
+		t.Errorf("esc matches %q, want quit", action)
+	}
+}
+
+func TestKeymap_Handler(t *testing.T) {
+	m := newTestKeymap(t)
+	cursor := 0
+	createView := func() *tui.View {
+		return tui.VStack(
+			tui.Fmt("cursor: %d", cursor).KeySequenceHandler(m.Handler(map[string]func() any{
+				"up":     func() any { cursor--; return true },
+				"down":   func() any { cursor++; return true },
+				"top":    func() any { cursor = 0; return true },
+				"bottom": func() any { cursor = 9; return true },
+			})).AbsoluteSize(0, 1),
+			HelpView(m).AbsoluteSize(0, 1),
+		).KeySequenceHandler(m.Handler(map[string]func() any{
+			"quit": func() any { return tui.Terminate },
+		}))
+	}
+	s, err := tui.NewScreen(createView, 80, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SendKeys('j', key.ArrowDown, 'k', 'x')
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "cursor: 1\nup/k up • down/j down • q/ctrl+c quit • g g top • g e/\\ j bottom"
+	if got := s.Text(); got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	err = s.SendKeys('g')
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "cursor: 1\ng: g top • e bottom"
+	if got := s.Text(); got != want {
+		t.Errorf("pending: text = %q, want %q", got, want)
+	}
+	err = s.SendKeys('e')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s.Text(), "cursor: 9\n") {
+		t.Errorf("g e: text = %q", s.Text())
+	}
+	err = s.SendKeys('g', 'g')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s.Text(), "cursor: 0\n") {
+		t.Errorf("g g: text = %q", s.Text())
+	}
+	err = s.SendKeys('q')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsTerminated() {
+		t.Error("q did not terminate the program")
+	}
+}
+
+func TestFullHelpView(t *testing.T) {
+	m := newTestKeymap(t)
+	s, err := tui.NewScreen(func() *tui.View { return FullHelpView(m) }, 20, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "up/k      up\ndown/j    down\nq/ctrl+c  quit\ng g       top\ng e/\\ j   bottom"
+	if got := s.Text(); got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
@@ -1,21 +1,197 @@
 package tui
 
-import "github.com/dytlzl/tervi/pkg/key"
+import (
+	"time"
 
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
+// readBuffer reads a key from the head of the buffer.
+// Escape sequences of CSI (ESC [) and SS3 (ESC O) are decoded into the codes of the key package
+// combined with the modifiers, and a key preceded by ESC is read as the key with Alt.
+// An incomplete escape sequence is read as it is, so the caller should wait for the rest
+// while isIncomplete reports true.
 func readBuffer(buffer []rune) (rune, int) {
 	if len(buffer) == 0 {
 		return 0, 0
 	}
-	if len(buffer) > 2 {
-		v := buffer[0]*0x10000 + buffer[1]*0x100 + buffer[2]
-		switch v {
-		// ^[[A
-		case key.ArrowUp, key.ArrowDown, key.ArrowRight, key.ArrowLeft:
-			return v, 3
-		// ^[OA
-		case key.ArrowUp - 0xc00, key.ArrowDown - 0xc00, key.ArrowRight - 0xc00, key.ArrowLeft - 0xc00:
-			return v + 0xc00, 3
+	if buffer[0] != key.Esc || len(buffer) == 1 {
+		return buffer[0], 1
+	}
+	switch buffer[1] {
+	case '[':
+		if ch, _, size := readCSI(buffer); size > 0 {
+			return ch, size
+		}
+	case 'O':
+		if len(buffer) > 2 {
+			return readSS3(buffer[2]), 3
+		}
+	case key.Esc:
+		// An escape sequence preceded by ESC
+		if len(buffer) > 2 && (buffer[2] == '[' || buffer[2] == 'O') {
+			ch, size := readBuffer(buffer[1:])
+			if ch == key.Unknown {
+				return ch, size + 1
+			}
+			return ch | key.ModAlt, size + 1
+		}
+	}
+	return buffer[1] | key.ModAlt, 2
+}
+
+// readCSI reads a CSI sequence: ESC [, parameter bytes, intermediate bytes and a final byte.
+// The action is read from the event type of the kitty keyboard protocol (ESC [ code ; modifier : event u).
+// It returns 0 as the size if the sequence is incomplete.
+func readCSI(buffer []rune) (rune, key.Action, int) {
+	params := [2]int{}
+	event := 0
+	i := 0
+	isSub := false
+	isPrivate := false
+	for n := 2; n < len(buffer); n++ {
+		r := buffer[n]
+		switch {
+		case r >= '0' && r <= '9':
+			switch {
+			case isSub:
+				if i == 1 {
+					event = event*10 + int(r-'0')
+				}
+			case i < len(params):
+				params[i] = params[i]*10 + int(r-'0')
+			}
+		case r == ';':
+			i++
+			isSub = false
+		case r == ':':
+			isSub = true
+		case r >= 0x3c && r <= 0x3f:
+			// Private markers
+			isPrivate = true
+		case r >= 0x20 && r <= 0x2f:
+			// Intermediate bytes
+			isPrivate = true
+		case r >= 0x40 && r <= 0x7e:
+			if isPrivate {
+				return key.Unknown, key.Press, n + 1
+			}
+			return decodeCSI(params[0], params[1], r), keyAction(event), n + 1
+		default:
+			return key.Unknown, key.Press, n
+		}
+	}
+	return 0, key.Press, 0
+}
+
+func keyAction(event int) key.Action {
+	switch event {
+	case 2:
+		return key.Repeat
+	case 3:
+		return key.Release
+	}
+	return key.Press
+}
+
+func decodeCSI(param, modifier int, final rune) rune {
+	code := key.Unknown
+	switch final {
+	case 'A', 'B', 'C', 'D', 'F', 'H', 'Z':
+		code = 0x1b5b00 + final
+	case 'P', 'Q', 'R', 'S':
+		code = 0x1b4f00 + final
+	case '~':
+		switch param {
+		case 1, 7:
+			code = key.Home
+		case 4, 8:
+			code = key.End
+		case 2, 3, 5, 6, 15, 17, 18, 19, 20, 21, 23, 24:
+			code = 0x1b7e00 + rune(param)
+		}
+	case 'u':
+		code = rune(param)
+	}
+	if code == key.Unknown {
+		return code
+	}
+	return code | modifiers(modifier)
+}
+
+// modifiers converts the modifier parameter of xterm into the modifiers of the key package.
+func modifiers(param int) rune {
+	if param <= 1 {
+		return 0
+	}
+	bits := param - 1
+	var mod rune
+	if bits&1 != 0 {
+		mod |= key.ModShift
+	}
+	// Meta is treated as Alt.
+	if bits&(2|8) != 0 {
+		mod |= key.ModAlt
+	}
+	if bits&4 != 0 {
+		mod |= key.ModCtrl
+	}
+	return mod
+}
+
+func readSS3(final rune) rune {
+	switch final {
+	case 'A', 'B', 'C', 'D', 'F', 'H':
+		return 0x1b5b00 + final
+	case 'P', 'Q', 'R', 'S':
+		return 0x1b4f00 + final
+	}
+	return key.Unknown
+}
+
+// readInput reads a key event, a mouse event or a paste from the head of the buffer.
+// It returns 0 as the size while a paste is not read to the end, and paste keeps how far it is searched.
+func readInput(buffer []rune, paste *pasteReader) (any, int) {
+	if e, size, ok := readMouse(buffer); ok {
+		return e, size
+	}
+	if p, size, ok := paste.read(buffer); ok {
+		if size == 0 {
+			return nil, 0
 		}
+		return p, size
+	}
+	ch, size := readBuffer(buffer)
+	if size == 0 {
+		return nil, 0
+	}
+	e := key.FromRune(ch)
+	if len(buffer) > 2 && buffer[0] == key.Esc && buffer[1] == '[' {
+		_, e.Action, _ = readCSI(buffer)
+	}
+	return e, size
+}
+
+// escapeTimeout is how long to wait for the rest of an escape sequence
+// before reading the escape key by itself.
+const escapeTimeout = 25 * time.Millisecond
+
+// isIncomplete reports whether the buffer ends in the middle of an escape sequence.
+func isIncomplete(buffer []rune) bool {
+	if len(buffer) == 0 || buffer[0] != key.Esc {
+		return false
+	}
+	if len(buffer) == 1 {
+		return true
+	}
+	switch buffer[1] {
+	case '[':
+		_, _, size := readCSI(buffer)
+		return size == 0
+	case 'O':
+		return len(buffer) == 2
+	case key.Esc:
+		return len(buffer) == 2 || isIncomplete(buffer[1:]) && (buffer[2] == '[' || buffer[2] == 'O')
 	}
-	return buffer[0], 1
+	return false
 }
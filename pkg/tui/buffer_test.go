@@ -40,6 +40,90 @@ func Test_readBuffer(t *testing.T) {
 			wantKey:    key.ArrowUp,
 			wantLength: 3,
 		},
+		{
+			name:       "return Esc and 1 when only ^[ in buffer",
+			buffer:     []rune{0x1b},
+			wantKey:    key.Esc,
+			wantLength: 1,
+		},
+		{
+			name:       "return Home when ^[[H in buffer",
+			buffer:     []rune("\x1b[Hx"),
+			wantKey:    key.Home,
+			wantLength: 3,
+		},
+		{
+			name:       "return End when ^[[4~ in buffer",
+			buffer:     []rune("\x1b[4~"),
+			wantKey:    key.End,
+			wantLength: 4,
+		},
+		{
+			name:       "return PageDown when ^[[6~ in buffer",
+			buffer:     []rune("\x1b[6~"),
+			wantKey:    key.PageDown,
+			wantLength: 4,
+		},
+		{
+			name:       "return Delete with Shift when ^[[3;2~ in buffer",
+			buffer:     []rune("\x1b[3;2~"),
+			wantKey:    key.Delete | key.ModShift,
+			wantLength: 6,
+		},
+		{
+			name:       "return F1 when ^[OP in buffer",
+			buffer:     []rune("\x1bOP"),
+			wantKey:    key.F1,
+			wantLength: 3,
+		},
+		{
+			name:       "return F12 when ^[[24~ in buffer",
+			buffer:     []rune("\x1b[24~"),
+			wantKey:    key.F12,
+			wantLength: 5,
+		},
+		{
+			name:       "return ArrowLeft with Ctrl when ^[[1;5D in buffer",
+			buffer:     []rune("\x1b[1;5D"),
+			wantKey:    key.ArrowLeft | key.ModCtrl,
+			wantLength: 6,
+		},
+		{
+			name:       "return ArrowUp with Shift, Alt and Ctrl when ^[[1;8A in buffer",
+			buffer:     []rune("\x1b[1;8A"),
+			wantKey:    key.ArrowUp | key.ModShift | key.ModAlt | key.ModCtrl,
+			wantLength: 6,
+		},
+		{
+			name:       "return BackTab when ^[[Z in buffer",
+			buffer:     []rune("\x1b[Z"),
+			wantKey:    key.BackTab,
+			wantLength: 3,
+		},
+		{
+			name:       "return a key with Alt when it is preceded by ^[",
+			buffer:     []rune("\x1bx"),
+			wantKey:    'x' | key.ModAlt,
+			wantLength: 2,
+		},
+		{
+			name:       "return ArrowRight with Alt when ^[^[[C in buffer",
+			buffer:     []rune("\x1b\x1b[C"),
+			wantKey:    key.ArrowRight | key.ModAlt,
+			wantLength: 4,
+		},
+		{
+			name:       "return a key with modifiers when CSI u in buffer",
+			buffer:     []rune("\x1b[97;5u"),
+			wantKey:    'a' | key.ModCtrl,
+			wantLength: 7,
+		},
+		{
+			name:       "return Unknown and the whole length when an unknown sequence in buffer",
+			buffer:     []rune("\x1b[?1;2cx"),
+			wantKey:    key.Unknown,
+			wantLength: 7,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -53,3 +137,180 @@ func Test_readBuffer(t *testing.T) {
 		})
 	}
 }
+
+func Test_isIncomplete(t *testing.T) {
+	tests := []struct {
+		name   string
+		buffer []rune
+		want   bool
+	}{
+		{name: "empty", buffer: []rune{}, want: false},
+		{name: "escape alone", buffer: []rune{0x1b}, want: true},
+		{name: "CSI introducer", buffer: []rune{0x1b, '['}, want: true},
+		{name: "SS3 introducer", buffer: []rune{0x1b, 'O'}, want: true},
+		{name: "complete sequence", buffer: []rune{0x1b, '[', 'A'}, want: false},
+		{name: "sequence with parameters", buffer: []rune("\x1b[1;5"), want: true},
+		{name: "escape before escape", buffer: []rune("\x1b\x1b"), want: true},
+		{name: "sequence after escape", buffer: []rune("\x1b\x1b[1"), want: true},
+		{name: "key with Alt", buffer: []rune("\x1bx"), want: false},
+		{name: "plain character", buffer: []rune{'a'}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isIncomplete(tt.buffer); got != tt.want {
+				t.Errorf("isIncomplete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		buffer     []rune
+		want       any
+		wantLength int
+	}{
+		{
+			name:       "character",
+			buffer:     []rune("ab"),
+			want:       key.Event{Code: 'a', Rune: 'a'},
+			wantLength: 1,
+		},
+		{
+			name:       "control character",
+			buffer:     []rune{key.CtrlP},
+			want:       key.Event{Code: 'p', Mod: key.Ctrl},
+			wantLength: 1,
+		},
+		{
+			name:       "arrow with modifiers",
+			buffer:     []rune("\x1b[1;6D"),
+			want:       key.Event{Code: key.ArrowLeft, Mod: key.Ctrl | key.Shift},
+			wantLength: 6,
+		},
+		{
+			name:       "kitty key",
+			buffer:     []rune("\x1b[112;5u"),
+			want:       key.Event{Code: 'p', Mod: key.Ctrl},
+			wantLength: 8,
+		},
+		{
+			name:       "kitty key repeated",
+			buffer:     []rune("\x1b[97;1:2u"),
+			want:       key.Event{Code: 'a', Rune: 'a', Action: key.Repeat},
+			wantLength: 9,
+		},
+		{
+			name:       "kitty key released",
+			buffer:     []rune("\x1b[13;3:3u"),
+			want:       key.Event{Code: key.Enter, Mod: key.Alt, Action: key.Release},
+			wantLength: 9,
+		},
+		{
+			name:       "mouse",
+			buffer:     []rune("\x1b[<0;2;3M"),
+			want:       MouseEvent{X: 1, Y: 2, Button: MouseLeft, Action: MousePress},
+			wantLength: 9,
+		},
+		{
+			name:       "paste",
+			buffer:     []rune("\x1b[200~a\x1b[Ab\r\nc\rd\x1b[201~e"),
+			want:       Paste{Text: "a\x1b[Ab\nc\nd"},
+			wantLength: 22,
+		},
+		{
+			name:       "paste not read to the end",
+			buffer:     []rune("\x1b[200~abc\x1b[201"),
+			want:       nil,
+			wantLength: 0,
+		},
+		{
+			name:       "empty",
+			buffer:     []rune{},
+			want:       nil,
+			wantLength: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotLength := readInput(tt.buffer, &pasteReader{})
+			if got != tt.want {
+				t.Errorf("readInput() got = %+v, want %+v", got, tt.want)
+			}
+			if gotLength != tt.wantLength {
+				t.Errorf("readInput() got length = %v, want %v", gotLength, tt.wantLength)
+			}
+		})
+	}
+}
+
+func Test_pasteReader_read(t *testing.T) {
+	var r pasteReader
+	// The end of the paste arrives in pieces, and the paste after it is read with the same reader.
+	input := []rune("\x1b[200~ab\x1b[201~\x1b[200~c\x1b[201~")
+	for _, n := range []int{6, 9, 11, 13} {
+		if _, size, ok := r.read(input[:n]); !ok || size != 0 {
+			t.Fatalf("read(%q) = %v, %v, want an incomplete paste", string(input[:n]), size, ok)
+		}
+	}
+	p, size, ok := r.read(input[:14])
+	if !ok || p.Text != "ab" || size != 14 {
+		t.Fatalf("read() = %+v, %v, %v, want %q of 14 runes", p, size, ok, "ab")
+	}
+	p, size, ok = r.read(input[size:])
+	if !ok || p.Text != "c" || size != 13 {
+		t.Errorf("read() = %+v, %v, %v, want %q of 13 runes", p, size, ok, "c")
+	}
+}
+
+func Test_pasteReader_readIncomplete(t *testing.T) {
+	tests := []struct {
+		name       string
+		buffer     []rune
+		want       any
+		wantLength int
+	}{
+		{
+			name:       "text received so far",
+			buffer:     []rune("\x1b[200~ab\rc"),
+			want:       Paste{Text: "ab\nc"},
+			wantLength: 10,
+		},
+		{
+			name:       "text before Ctrl+C",
+			buffer:     []rune("\x1b[200~abcq\x03d"),
+			want:       Paste{Text: "abcq"},
+			wantLength: 10,
+		},
+		{
+			name:       "no text",
+			buffer:     []rune("\x1b[200~\x03"),
+			want:       nil,
+			wantLength: 6,
+		},
+		{
+			name:       "complete paste",
+			buffer:     []rune("\x1b[200~a\x1b[201~"),
+			want:       nil,
+			wantLength: 0,
+		},
+		{
+			name:       "key",
+			buffer:     []rune("a"),
+			want:       nil,
+			wantLength: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotLength := (&pasteReader{}).readIncomplete(tt.buffer)
+			if got != tt.want {
+				t.Errorf("readIncomplete() got = %+v, want %+v", got, tt.want)
+			}
+			if gotLength != tt.wantLength {
+				t.Errorf("readIncomplete() got length = %v, want %v", gotLength, tt.wantLength)
+			}
+		})
+	}
+}
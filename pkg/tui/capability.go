@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dytlzl/tervi/pkg/color"
+)
+
+// DetectColorProfile decides the colors that the terminal can show
+// from NO_COLOR, COLORTERM, TERM and the terminfo database.
+// getenv is usually os.Getenv, or a lookup of the variables sent by a remote client.
+func DetectColorProfile(getenv func(string) string) color.Profile {
+	if getenv("NO_COLOR") != "" {
+		return color.Monochrome
+	}
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return color.TrueColor
+	}
+	term := getenv("TERM")
+	switch {
+	case term == "":
+		return color.ANSI16
+	case term == "dumb":
+		return color.Monochrome
+	case strings.HasSuffix(term, "-direct"), strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"):
+		return color.TrueColor
+	}
+	if colors, err := terminfoColors(term, getenv); err == nil {
+		switch {
+		case colors >= 1<<24:
+			return color.TrueColor
+		case colors >= 256:
+			return color.ANSI256
+		case colors >= 8:
+			return color.ANSI16
+		default:
+			return color.Monochrome
+		}
+	}
+	if strings.Contains(term, "256color") {
+		return color.ANSI256
+	}
+	return color.ANSI16
+}
+
+// maxTerminfoSize limits the size of a terminfo entry to read. The entries are a few kilobytes.
+const maxTerminfoSize = 64 << 10
+
+// terminfoColors reads the max_colors capability of the terminal from the terminfo database.
+// It reads no files for the names of terminals that are not names of files in the database,
+// because TERM may be sent by a remote client.
+func terminfoColors(term string, getenv func(string) string) (int, error) {
+	if term == "" || strings.HasPrefix(term, ".") || strings.ContainsAny(term, "/\\") || strings.Contains(term, "..") {
+		return 0, fmt.Errorf("invalid terminal name %q", term)
+	}
+	for _, dir := range terminfoDirs(getenv) {
+		for _, sub := range []string{term[:1], fmt.Sprintf("%x", term[0])} {
+			data, err := readTerminfo(filepath.Join(dir, sub, term))
+			if err != nil {
+				continue
+			}
+			return parseTerminfoColors(data)
+		}
+	}
+	return 0, fmt.Errorf("terminfo entry of %s is not found", term)
+}
+
+// readTerminfo reads at most maxTerminfoSize bytes of the file.
+func readTerminfo(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxTerminfoSize))
+}
+
+func terminfoDirs(getenv func(string) string) []string {
+	dirs := make([]string, 0, 8)
+	if dir := getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home := getenv("HOME"); home != "" {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	defaultDirs := []string{"/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo", "/usr/lib/terminfo"}
+	if terminfoDirs := getenv("TERMINFO_DIRS"); terminfoDirs != "" {
+		for _, dir := range strings.Split(terminfoDirs, ":") {
+			if dir == "" {
+				dirs = append(dirs, defaultDirs...)
+			} else {
+				dirs = append(dirs, dir)
+			}
+		}
+		return dirs
+	}
+	return append(dirs, defaultDirs...)
+}
+
+const (
+	terminfoMagic         = 0432
+	terminfoMagicExtended = 01036
+	terminfoIndexColors   = 13
+)
+
+// parseTerminfoColors reads max_colors from a compiled terminfo entry.
+// It returns -1 when the capability is absent.
+func parseTerminfoColors(data []byte) (int, error) {
+	if len(data) < 12 {
+		return 0, fmt.Errorf("terminfo entry is too short")
+	}
+	header := make([]int, 6)
+	for i := range header {
+		header[i] = int(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	numberSize := 2
+	switch header[0] {
+	case terminfoMagic:
+	case terminfoMagicExtended:
+		numberSize = 4
+	default:
+		return 0, fmt.Errorf("invalid magic number of terminfo: %o", header[0])
+	}
+	namesSize, boolCount, numberCount := header[1], header[2], header[3]
+	if numberCount <= terminfoIndexColors {
+		return -1, nil
+	}
+	offset := 12 + namesSize + boolCount
+	if offset%2 == 1 {
+		offset++
+	}
+	offset += terminfoIndexColors * numberSize
+	if offset+numberSize > len(data) {
+		return 0, fmt.Errorf("terminfo entry is too short")
+	}
+	if numberSize == 2 {
+		return int(int16(binary.LittleEndian.Uint16(data[offset:]))), nil
+	}
+	return int(int32(binary.LittleEndian.Uint32(data[offset:]))), nil
+}
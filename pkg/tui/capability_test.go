@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dytlzl/tervi/pkg/color"
+)
+
+// writeTerminfo writes a minimal compiled terminfo entry that has only max_colors.
+func writeTerminfo(t *testing.T, dir, term string, colors int) {
+	t.Helper()
+	name := []byte(term + "\x00")
+	data := make([]byte, 0, 64)
+	for _, v := range []int{terminfoMagic, len(name), 0, terminfoIndexColors + 1, 0, 0} {
+		data = appendUint16(data, uint16(v))
+	}
+	data = append(data, name...)
+	if len(name)%2 == 1 {
+		data = append(data, 0)
+	}
+	for i := 0; i < terminfoIndexColors; i++ {
+		data = appendUint16(data, 0xffff)
+	}
+	data = appendUint16(data, uint16(colors))
+	path := filepath.Join(dir, term[:1], term)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendUint16(data []byte, v uint16) []byte {
+	return append(data, byte(v), byte(v>>8))
+}
+
+func Test_DetectColorProfile(t *testing.T) {
+	terminfo := t.TempDir()
+	writeTerminfo(t, terminfo, "fancy", 256)
+	writeTerminfo(t, terminfo, "basic", 8)
+	writeTerminfo(t, terminfo, "mono", 0xffff)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want color.Profile
+	}{
+		{
+			name: "NO_COLOR wins over everything",
+			env:  map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor", "TERM": "xterm-256color"},
+			want: color.Monochrome,
+		},
+		{
+			name: "COLORTERM=truecolor",
+			env:  map[string]string{"COLORTERM": "truecolor", "TERM": "xterm"},
+			want: color.TrueColor,
+		},
+		{
+			name: "TERM=dumb",
+			env:  map[string]string{"TERM": "dumb"},
+			want: color.Monochrome,
+		},
+		{
+			name: "direct color TERM",
+			env:  map[string]string{"TERM": "xterm-direct"},
+			want: color.TrueColor,
+		},
+		{
+			name: "256 colors in terminfo",
+			env:  map[string]string{"TERM": "fancy", "TERMINFO": terminfo},
+			want: color.ANSI256,
+		},
+		{
+			name: "8 colors in terminfo",
+			env:  map[string]string{"TERM": "basic", "TERMINFO": terminfo},
+			want: color.ANSI16,
+		},
+		{
+			name: "no colors in terminfo",
+			env:  map[string]string{"TERM": "mono", "TERMINFO": terminfo},
+			want: color.Monochrome,
+		},
+		{
+			name: "256color suffix without terminfo",
+			env:  map[string]string{"TERM": "unknown-256color", "TERMINFO_DIRS": terminfo},
+			want: color.ANSI256,
+		},
+		{
+			name: "TERM out of the terminfo database",
+			env:  map[string]string{"TERM": "../f/fancy", "TERMINFO": filepath.Join(terminfo, "x")},
+			want: color.ANSI16,
+		},
+		{
+			name: "TERM of a hidden file",
+			env:  map[string]string{"TERM": ".fancy", "TERMINFO": terminfo},
+			want: color.ANSI16,
+		},
+		{
+			name: "no TERM",
+			env:  map[string]string{},
+			want: color.ANSI16,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := DetectColorProfile(getenv); got != tt.want {
+				t.Errorf("DetectColorProfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,11 @@
 package tui
 
 import (
-	"fmt"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/dytlzl/tervi/pkg/color"
 	"golang.org/x/term"
 )
 
@@ -23,53 +24,70 @@ type cellWriter interface {
 	size() (int, int)
 	matrix() [][]cell
 	put(c cell, x, y int)
+	fill(s style)
+	// setHeight changes the height in inline mode within the height of the terminal.
+	setHeight(height int)
 }
 
 type generalCellWriter struct {
 	isAlternative bool
 	width         int
 	height        int
+	maxHeight     int // height of the terminal
 	rows          [][]cell
-	buffer        []string
-	backBuffer    []string
+	front         [][]cell // cells shown on the terminal, nil when they are unknown
+	out           []byte
+	output        io.Writer
+	pen           style // style set on the terminal
+	x             int   // column of the terminal cursor, -1 when it is unknown
+	y             int   // row of the terminal cursor
 	cursorX       int
 	cursorY       int
-	eventChan     chan any
-	ttyin         *os.File
+	profile       color.Profile
+	getSize       func() (int, int, error)
+	ttyin         *os.File // terminal set to raw mode, nil when the caller manages it
 	oldState      *term.State
+	benchmarker   *Benchmarker
 }
 
-func newGeneralCellWriter(isAlternative bool) (*generalCellWriter, error) {
-	ttyin := os.Stdin
-	state, err := term.MakeRaw(int(ttyin.Fd()))
-	if err != nil {
-		return nil, err
+// newGeneralCellWriter starts drawing on the terminal of cfg.
+// When no terminal is given by OptionIO, the terminal of the standard input is set to raw mode
+// and the output is written to the standard error.
+func newGeneralCellWriter(isAlternative bool, cfg *config) (*generalCellWriter, error) {
+	w := &generalCellWriter{
+		isAlternative: isAlternative,
+		out:           make([]byte, 0, 4096),
+		output:        cfg.output,
+		getSize:       cfg.getSize,
+	}
+	if w.output == nil {
+		ttyin := os.Stdin
+		state, err := term.MakeRaw(int(ttyin.Fd()))
+		if err != nil {
+			return nil, err
+		}
+		w.output = os.Stderr
+		w.getSize = func() (int, int, error) {
+			return term.GetSize(int(ttyin.Fd()))
+		}
+		w.ttyin = ttyin
+		w.oldState = state
 	}
-	height := 20
 	if !isAlternative {
-		push(strings.Repeat("\n", height-2))
-		flush()
+		// The rows start from the line of the cursor, and are added by setHeight.
+		w.push("\r")
 	}
-	initRenderer(isAlternative)
-	return &generalCellWriter{
-		isAlternative: isAlternative,
-		height:        If(isAlternative, 0, height),
-		cursorY:       If(isAlternative, 0, height-1),
-		buffer:        make([]string, 1024),
-		backBuffer:    make([]string, 1024),
-		ttyin:         ttyin,
-		eventChan:     make(chan any, 64),
-		oldState:      state,
-	}, nil
-}
-
-func initRenderer(isAlternative bool) {
-	csi("s")
+	w.initRenderer(isAlternative)
+	return w, nil
+}
+
+func (w *generalCellWriter) initRenderer(isAlternative bool) {
+	w.csi("s")
 	if isAlternative {
-		smcup()
+		w.smcup()
 	}
-	hideCursor()
-	flush()
+	w.hideCursor()
+	w.flush()
 }
 
 func (w *generalCellWriter) size() (int, int) {
@@ -81,48 +99,62 @@ func (w *generalCellWriter) matrix() [][]cell {
 }
 
 func (w *generalCellWriter) close(isAlternative bool) error {
-	showCursor()
 	if isAlternative {
-		rmcup()
-		csi("u")
-		flush()
-		err := term.Restore(w.fd(), w.oldState)
-		if err != nil {
-			return err
-		}
+		w.showCursor()
+		w.rmcup()
+		w.csi("u")
 	} else {
-		csi(fmt.Sprintf("%dB", w.height-w.cursorY-2))
-		push("\n")
-		flush()
-		csi("1A")
-		flush()
-		err := term.Restore(w.fd(), w.oldState)
-		push("\n")
-		flush()
+		// Leave the last frame above the prompt.
+		if w.height > 0 {
+			w.moveTo(0, w.height-1)
+			w.out = append(w.out, "\x1b[0m\r\n"...)
+		}
+		w.showCursor()
+	}
+	w.flush()
+	if w.ttyin != nil {
+		return term.Restore(int(w.ttyin.Fd()), w.oldState)
+	}
+	return nil
+}
+
+// resume takes the terminal back after close or after the process is continued,
+// and draws all the cells again by the next draw.
+// In inline mode, the rows start again from the line of the cursor.
+func (w *generalCellWriter) resume() error {
+	if w.ttyin != nil {
+		// The state to be restored by close is kept.
+		_, err := term.MakeRaw(int(w.ttyin.Fd()))
 		if err != nil {
 			return err
 		}
 	}
-
+	if !w.isAlternative {
+		w.push("\r")
+		w.x, w.y = 0, 0
+		w.height = 0
+		w.rows = newMatrix(w.width, 0)
+	}
+	w.initRenderer(w.isAlternative)
+	w.front = nil
 	return nil
 }
 
 func (w *generalCellWriter) updateTerminalSize() (bool, error) {
-	width, height, err := term.GetSize(w.fd())
-	if !w.isAlternative {
-		height = w.height
-	}
+	width, height, err := w.getSize()
 	if err != nil {
 		return false, err
 	}
+	w.maxHeight = height
+	if !w.isAlternative {
+		height = If(w.height > height, height, w.height)
+	}
 	hasChanged := w.width != width || w.height != height
 	if hasChanged {
 		w.width = width
 		w.height = height
-		w.rows = make([][]cell, w.height)
-		for y := 0; y < w.height; y++ {
-			w.rows[y] = make([]cell, w.width)
-		}
+		w.rows = newMatrix(width, height)
+		w.front = nil
 	}
 	return hasChanged, nil
 }
@@ -135,81 +167,60 @@ func (w *generalCellWriter) fill(s style) {
 	}
 }
 
-func (w *generalCellWriter) put(c cell, x, y int) {
-	if w.rows[y][x] != c {
-		w.rows[y][x] = c
+// setHeight changes the number of the rows in inline mode.
+// The terminal is scrolled if there is no room for the new rows below the cursor.
+func (w *generalCellWriter) setHeight(height int) {
+	if w.isAlternative {
+		return
+	}
+	height = If(height > w.maxHeight, w.maxHeight, height)
+	if height == w.height {
+		return
+	}
+	if height > w.height {
+		last := If(w.height > 0, w.height-1, 0)
+		w.moveTo(0, last)
+		for y := last; y < height-1; y++ {
+			w.out = append(w.out, '\n')
+		}
+		w.y = height - 1
 	}
+	w.height = height
+	w.rows = newMatrix(w.width, height)
+	// The rows are cleared and drawn again.
+	w.front = nil
 }
 
-func (w *generalCellWriter) draw() {
+// printLines writes the text above the rows in inline mode, where it stays when the rows are drawn again.
+// The rows move down below the text, and are drawn again by the next draw.
+func (w *generalCellWriter) printLines(text string) {
 	if w.isAlternative {
-		origin()
-	} else {
-		csi(fmt.Sprintf("%dA", w.cursorY-1))
-		push("\r")
+		return
 	}
+	rows := w.rows
+	w.moveTo(0, 0)
+	w.out = append(w.out, "\x1b[0m\x1b[J"...)
+	w.pen = style{}
+	// The terminal does not translate newlines in raw mode.
+	w.out = append(w.out, strings.ReplaceAll(text, "\n", "\r\n")...)
+	w.out = append(w.out, "\r\n"...)
+	w.x, w.y = 0, 0
+	height := w.height
+	w.height = 0
+	w.setHeight(height)
+	w.rows = rows
+}
 
-	for y := 0; y < w.height; y++ {
-		w.buffer[y] = "\r"
-		lastStyle := style{}
-		for x := 0; x < w.width; x++ {
-			s := w.rows[y][x].Style
-			if s != lastStyle {
-				w.buffer[y] += "\033[1;0m"
-				if s.f256 != 0 {
-					w.buffer[y] += fmt.Sprintf("\033[38;5;%dm", s.f256)
-				}
-				if s.b256 != 0 {
-					w.buffer[y] += fmt.Sprintf("\033[48;5;%dm", s.b256)
-				}
-				if s.bold {
-					w.buffer[y] += "\033[1m"
-				}
-				if s.italic {
-					w.buffer[y] += "\033[3m"
-				}
-				if s.underline {
-					w.buffer[y] += "\033[4m"
-				}
-				if s.reverse {
-					w.buffer[y] += "\033[7m"
-				}
-				if s.strikethrough {
-					w.buffer[y] += "\033[9m"
-				}
-
-				lastStyle = s
-			}
-
-			if s.hasCursor {
-				w.cursorY = y
-				w.cursorX = x
-			}
-			if !(w.rows[y][x].Width == 0 && w.rows[y][x-1].Width == 2) {
-				w.buffer[y] += string(w.rows[y][x].Char)
-			}
-		}
-		if w.backBuffer[y] == w.buffer[y] {
-			w.buffer[y] = ""
-		} else {
-			w.backBuffer[y] = w.buffer[y]
-		}
-	}
-	push(strings.Join(w.buffer[:w.height], "\x1b[1B"))
-	push("\033[1;0m") // Reset Style
-	if w.isAlternative {
-		origin()
-	} else {
-		csi(fmt.Sprintf("%dA", w.height-1))
-		push("\r")
+func (w *generalCellWriter) put(c cell, x, y int) {
+	if w.rows[y][x] != c {
+		w.rows[y][x] = c
 	}
-	csi(fmt.Sprintf("%dB", w.cursorY))
-	csi(fmt.Sprintf("%dC", w.cursorX))
-	benchmarker.benchmark("lines")
-	flush()
-	benchmarker.benchmark("flush")
 }
 
-func (w *generalCellWriter) fd() int {
-	return int(w.ttyin.Fd())
+func newMatrix(width, height int) [][]cell {
+	rows := make([][]cell, height)
+	for y := 0; y < height; y++ {
+		rows[y] = make([]cell, width)
+	}
+	return rows
 }
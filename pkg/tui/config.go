@@ -1,9 +1,40 @@
 package tui
 
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/dytlzl/tervi/pkg/color"
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
 type config struct {
 	channel      chan any
-	viewPQ       priorityQueue
+	keyViews     []*View // views with handlers of keys or pastes in layout order
 	eventHandler func(any) any
+	colorProfile *color.Profile
+	mouse        bool
+	inline       bool
+	// isNaturalHeight is true while the views are laid out at their natural heights in inline mode.
+	isNaturalHeight bool
+	mouseViews      []*View
+	printed         []string // text to be printed above the views by Println
+	result          any      // result passed to TerminateWith
+	processes       []execProcess
+	focusedID       string
+	focusables      []*View // focusable views in layout order
+	suspend         bool
+	dispatchCtrlC   bool
+	pendingKeys     []key.Event // keys of an incomplete sequence. See KeySequenceHandler.
+	keyTimeout      time.Duration
+	input           io.Reader
+	output          io.Writer
+	getSize         func() (int, int, error)
+	resized         <-chan struct{}
+	debug           bool
+	execRunner      func(*exec.Cmd) error
 }
 
 func OptionChannel(ch chan any) func(*config) error {
@@ -20,4 +51,114 @@ func OptionEventHandler(fn func(any) any) func(*config) error {
 	}
 }
 
-type option = func(*config) error
+// OptionColorProfile overrides the colors that the terminal is detected to show.
+func OptionColorProfile(p color.Profile) func(*config) error {
+	return func(c *config) error {
+		c.colorProfile = &p
+		return nil
+	}
+}
+
+// OptionMouse enables the mouse reporting.
+// The mouse events are sent to the handlers set by OnClick, OnScroll and OnMouse,
+// and then to the event handler of OptionEventHandler as MouseEvent.
+func OptionMouse() func(*config) error {
+	return func(c *config) error {
+		c.mouse = true
+		return nil
+	}
+}
+
+// OptionInline renders the views below the cursor, e.g. below the prompt of a shell,
+// instead of on the alternate screen. The height follows the natural height of the views
+// within the height of the terminal, and the last frame is left on the terminal when Run returns.
+// Lines can be printed above the views by sending Println to the channel of OptionChannel.
+// Mouse reporting is not enabled in inline mode.
+func OptionInline() func(*config) error {
+	return func(c *config) error {
+		c.inline = true
+		return nil
+	}
+}
+
+// OptionSuspend makes Ctrl+Z suspend the program like shells do.
+// The terminal is restored before the process is stopped, and taken back when the process is continued.
+// It is not supported on Windows, and Run returns an error if it is used with OptionIO.
+func OptionSuspend() func(*config) error {
+	return func(c *config) error {
+		if !canSuspend {
+			return errors.New("suspending is not supported on this platform")
+		}
+		c.suspend = true
+		return nil
+	}
+}
+
+// OptionDispatchCtrlC dispatches Ctrl+C to the views and the event handler like other keys,
+// instead of ending the program.
+func OptionDispatchCtrlC() func(*config) error {
+	return func(c *config) error {
+		c.dispatchCtrlC = true
+		return nil
+	}
+}
+
+// defaultKeyTimeout is the default timeout of OptionKeyTimeout.
+const defaultKeyTimeout = time.Second
+
+// OptionKeyTimeout sets how long the keys of an incomplete sequence are kept pending
+// before they are given back to the handlers of single keys. The default is one second.
+// See KeySequenceHandler.
+func OptionKeyTimeout(timeout time.Duration) func(*config) error {
+	return func(c *config) error {
+		if timeout <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		c.keyTimeout = timeout
+		return nil
+	}
+}
+
+// OptionIO makes Run read the input from in and write the output to out
+// instead of the terminal of the standard input and the standard error,
+// e.g. to run on a pty or an SSH channel. The terminal is expected to be in raw mode already.
+// getSize returns the width and the height of the terminal,
+// and resized receives a value when the size changes. resized can be nil if the size never changes.
+// A Read of in in progress when Run returns is not interrupted, so close in to end it.
+func OptionIO(in io.Reader, out io.Writer, getSize func() (width, height int, err error), resized <-chan struct{}) func(*config) error {
+	return func(c *config) error {
+		if in == nil || out == nil || getSize == nil {
+			return errors.New("input, output and getSize must not be nil")
+		}
+		c.input = in
+		c.output = out
+		c.getSize = getSize
+		c.resized = resized
+		return nil
+	}
+}
+
+// OptionDebug makes Run log how long each frame takes to render and draw
+// to the debug server of cmd/debug, which also receives the logs of (*Program).Logger.
+func OptionDebug() func(*config) error {
+	return func(c *config) error {
+		c.debug = true
+		return nil
+	}
+}
+
+// OptionExecRunner makes Screen run the commands of ExecProcess with run, e.g. a fake in tests.
+// Without it, the callbacks of ExecProcess on a Screen receive an error without running the commands.
+// Run ignores it.
+func OptionExecRunner(run func(cmd *exec.Cmd) error) func(*config) error {
+	return func(c *config) error {
+		if run == nil {
+			return errors.New("run must not be nil")
+		}
+		c.execRunner = run
+		return nil
+	}
+}
+
+// Option configures Run.
+type Option = func(*config) error
@@ -0,0 +1,287 @@
+package tui
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/dytlzl/tervi/pkg/color"
+)
+
+// draw sends the cells that differ from those on the terminal,
+// moving the cursor and changing the style in the cheapest way.
+func (w *generalCellWriter) draw() {
+	if w.front == nil {
+		w.clear()
+	}
+	w.cursorX, w.cursorY = -1, -1
+	for y := 0; y < w.height; y++ {
+		front, back := w.front[y], w.rows[y]
+		for x := 0; x < w.width; x++ {
+			c := back[x]
+			if c.Style.hasCursor {
+				w.cursorX, w.cursorY = x, y
+				c.Style.hasCursor = false
+			}
+			c.Style.fg = w.profile.Convert(c.Style.fg)
+			c.Style.bg = w.profile.Convert(c.Style.bg)
+			if c == front[x] {
+				continue
+			}
+			if c.Width == 0 && x > 0 && back[x-1].Width == 2 {
+				// The right half of a wide character is drawn with the left half.
+				front[x] = c
+				continue
+			}
+			w.moveTo(x, y)
+			w.setStyle(c.Style)
+			if c.Char == 0 {
+				c.Char = ' '
+			}
+			w.out = utf8.AppendRune(w.out, c.Char)
+			front[x] = c
+			w.x += If(c.Width == 2, 2, 1)
+			if w.x >= w.width {
+				// The cursor is pending to wrap, so its column is no longer reliable.
+				w.x = -1
+			}
+		}
+	}
+	w.benchmarker.benchmark("lines")
+	if w.cursorY >= 0 {
+		w.moveTo(w.cursorX, w.cursorY)
+	}
+	w.flush()
+	w.benchmarker.benchmark("flush")
+}
+
+// clear erases the area of the rows on the terminal.
+func (w *generalCellWriter) clear() {
+	w.out = append(w.out, "\x1b[0m"...)
+	w.pen = style{}
+	if w.isAlternative {
+		w.out = append(w.out, "\x1b[H\x1b[2J"...)
+		w.x, w.y = 0, 0
+	} else {
+		w.moveTo(0, 0)
+		w.out = append(w.out, "\x1b[J"...)
+	}
+	w.front = newMatrix(w.width, w.height)
+	for y := range w.front {
+		for x := range w.front[y] {
+			w.front[y][x] = cell{' ', 1, style{}}
+		}
+	}
+}
+
+func (w *generalCellWriter) flush() {
+	_, _ = w.output.Write(w.out)
+	w.out = w.out[:0]
+}
+
+// moveTo moves the cursor to the cell, choosing the shortest of
+// rewriting the cells on the way, relative moves and an absolute move.
+func (w *generalCellWriter) moveTo(x, y int) {
+	if w.x == x && w.y == y {
+		return
+	}
+	if w.y == y && w.x >= 0 && w.x < x && x-w.x <= moveCost(x-w.x) && w.canRewrite(y, w.x, x) {
+		for i := w.x; i < x; i++ {
+			w.out = append(w.out, byte(w.front[y][i].Char))
+		}
+		w.x = x
+		return
+	}
+
+	verticalCost := 0
+	if y != w.y {
+		verticalCost = moveCost(abs(y - w.y))
+	}
+	// Carriage return and then forward
+	horizontalCost := 1
+	if x > 0 {
+		horizontalCost += moveCost(x)
+	}
+	useCR := true
+	if w.x >= 0 && x != w.x {
+		if cost := moveCost(abs(x - w.x)); cost < horizontalCost {
+			horizontalCost = cost
+			useCR = false
+		}
+	} else if w.x == x {
+		horizontalCost = 0
+		useCR = false
+	}
+
+	if w.isAlternative && absoluteMoveCost(x, y) < verticalCost+horizontalCost {
+		w.out = append(w.out, "\x1b["...)
+		w.out = strconv.AppendInt(w.out, int64(y+1), 10)
+		if x > 0 {
+			w.out = append(w.out, ';')
+			w.out = strconv.AppendInt(w.out, int64(x+1), 10)
+		}
+		w.out = append(w.out, 'H')
+		w.x, w.y = x, y
+		return
+	}
+
+	switch {
+	case y > w.y:
+		w.out = appendMove(w.out, y-w.y, 'B')
+	case y < w.y:
+		w.out = appendMove(w.out, w.y-y, 'A')
+	}
+	switch {
+	case useCR:
+		w.out = append(w.out, '\r')
+		if x > 0 {
+			w.out = appendMove(w.out, x, 'C')
+		}
+	case x > w.x:
+		w.out = appendMove(w.out, x-w.x, 'C')
+	case x < w.x:
+		w.out = appendMove(w.out, w.x-x, 'D')
+	}
+	w.x, w.y = x, y
+}
+
+// canRewrite reports whether the cells from x1 to x2 can be written again
+// with the current style to move the cursor over them.
+func (w *generalCellWriter) canRewrite(y, x1, x2 int) bool {
+	for x := x1; x < x2; x++ {
+		c := w.front[y][x]
+		if c.Width != 1 || c.Char < ' ' || c.Char >= utf8.RuneSelf || c.Style != w.pen {
+			return false
+		}
+	}
+	return true
+}
+
+// setStyle changes the style on the terminal by one SGR sequence
+// that includes only the attributes and colors to be changed.
+func (w *generalCellWriter) setStyle(s style) {
+	if s == w.pen {
+		return
+	}
+	w.out = append(w.out, "\x1b["...)
+	start := len(w.out)
+	param := func(p string) {
+		if len(w.out) > start {
+			w.out = append(w.out, ';')
+		}
+		w.out = append(w.out, p...)
+	}
+	removed := w.pen.attr &^ s.attr
+	added := s.attr &^ w.pen.attr
+	if removed&attrBold != 0 {
+		param("22")
+	}
+	if removed&attrItalic != 0 {
+		param("23")
+	}
+	if removed&attrUnderline != 0 {
+		param("24")
+	}
+	if removed&attrReverse != 0 {
+		param("27")
+	}
+	if removed&attrStrikethrough != 0 {
+		param("29")
+	}
+	if added&attrBold != 0 {
+		param("1")
+	}
+	if added&attrItalic != 0 {
+		param("3")
+	}
+	if added&attrUnderline != 0 {
+		param("4")
+	}
+	if added&attrReverse != 0 {
+		param("7")
+	}
+	if added&attrStrikethrough != 0 {
+		param("9")
+	}
+	if s.fg != w.pen.fg {
+		if len(w.out) > start {
+			w.out = append(w.out, ';')
+		}
+		w.out = appendColor(w.out, s.fg, false)
+	}
+	if s.bg != w.pen.bg {
+		if len(w.out) > start {
+			w.out = append(w.out, ';')
+		}
+		w.out = appendColor(w.out, s.bg, true)
+	}
+	w.out = append(w.out, 'm')
+	w.pen = s
+}
+
+// appendColor appends the parameters of SGR for the color.
+func appendColor(b []byte, c color.Color, isBackground bool) []byte {
+	base := int64(30)
+	if isBackground {
+		base = 40
+	}
+	switch c.Kind() {
+	case color.KindANSI:
+		if c.Index() >= 8 {
+			return strconv.AppendInt(b, base+60+int64(c.Index())-8, 10)
+		}
+		return strconv.AppendInt(b, base+int64(c.Index()), 10)
+	case color.KindPalette:
+		b = strconv.AppendInt(b, base+8, 10)
+		b = append(b, ";5;"...)
+		return strconv.AppendInt(b, int64(c.Index()), 10)
+	case color.KindRGB:
+		r, g, bl := c.Values()
+		b = strconv.AppendInt(b, base+8, 10)
+		b = append(b, ";2;"...)
+		b = strconv.AppendInt(b, int64(r), 10)
+		b = append(b, ';')
+		b = strconv.AppendInt(b, int64(g), 10)
+		b = append(b, ';')
+		return strconv.AppendInt(b, int64(bl), 10)
+	}
+	return strconv.AppendInt(b, base+9, 10)
+}
+
+// appendMove appends a relative cursor movement, omitting the count when it is 1.
+func appendMove(b []byte, n int, direction byte) []byte {
+	b = append(b, "\x1b["...)
+	if n != 1 {
+		b = strconv.AppendInt(b, int64(n), 10)
+	}
+	return append(b, direction)
+}
+
+func moveCost(n int) int {
+	if n == 1 {
+		return 3
+	}
+	return 3 + digits(n)
+}
+
+func absoluteMoveCost(x, y int) int {
+	if x == 0 {
+		return 3 + digits(y+1)
+	}
+	return 4 + digits(y+1) + digits(x+1)
+}
+
+func digits(n int) int {
+	d := 1
+	for n >= 10 {
+		n /= 10
+		d++
+	}
+	return d
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
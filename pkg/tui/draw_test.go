@@ -0,0 +1,345 @@
+package tui
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dytlzl/tervi/pkg/color"
+	"github.com/mattn/go-runewidth"
+)
+
+// emulator interprets the subset of escape sequences that draw sends.
+type emulator struct {
+	t      *testing.T
+	width  int
+	height int
+	x, y   int
+	pen    style
+	cells  [][]cell
+	origin int // row where the rows of the writer start
+}
+
+func newEmulator(t *testing.T, width, height int) *emulator {
+	e := &emulator{t: t, width: width, height: height, cells: newMatrix(width, height)}
+	for y := range e.cells {
+		for x := range e.cells[y] {
+			e.cells[y][x] = cell{' ', 1, style{}}
+		}
+	}
+	return e
+}
+
+func (e *emulator) write(b []byte) {
+	s := []rune(string(b))
+	for i := 0; i < len(s); i++ {
+		switch r := s[i]; r {
+		case '\r':
+			e.x = 0
+		case '\n':
+			e.y++
+			if e.y == e.height {
+				// Scroll up
+				row := make([]cell, e.width)
+				for x := range row {
+					row[x] = cell{' ', 1, style{}}
+				}
+				e.cells = append(e.cells[1:], row)
+				e.y--
+				e.origin--
+			}
+		case 0x1b:
+			if i+1 >= len(s) || s[i+1] != '[' {
+				e.t.Fatalf("unexpected escape sequence in %q", b)
+			}
+			j := i + 2
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == ';') {
+				j++
+			}
+			e.csi(string(s[i+2:j]), s[j])
+			i = j
+		default:
+			if r < ' ' {
+				e.t.Fatalf("unexpected control character %q", r)
+			}
+			if e.x >= e.width {
+				e.x = 0
+				e.y++
+			}
+			width := runewidth.RuneWidth(r)
+			e.cells[e.y][e.x] = cell{r, width, e.pen}
+			if width == 2 {
+				e.cells[e.y][e.x+1] = cell{' ', 0, e.pen}
+			}
+			e.x += width
+		}
+	}
+}
+
+func (e *emulator) csi(params string, final rune) {
+	values := make([]int, 0)
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p)
+		values = append(values, n)
+	}
+	n := values[0]
+	if n == 0 {
+		n = 1
+	}
+	if final != 'm' && final != 'J' && e.x >= e.width {
+		e.x = e.width - 1
+	}
+	switch final {
+	case 'A':
+		e.y -= n
+	case 'B':
+		e.y += n
+	case 'C':
+		e.x += n
+	case 'D':
+		e.x -= n
+	case 'H':
+		e.y = n - 1
+		e.x = 0
+		if len(values) > 1 {
+			e.x = values[1] - 1
+		}
+	case 'J':
+		for y := e.y; y < e.height; y++ {
+			for x := 0; x < e.width; x++ {
+				e.cells[y][x] = cell{' ', 1, style{}}
+			}
+		}
+	case 'm':
+		e.sgr(values)
+	default:
+		e.t.Fatalf("unexpected CSI %q%c", params, final)
+	}
+	if e.x < 0 || e.x >= e.width || e.y < 0 || e.y >= e.height {
+		e.t.Fatalf("cursor is out of the screen: (%d, %d)", e.x, e.y)
+	}
+}
+
+func (e *emulator) sgr(values []int) {
+	for i := 0; i < len(values); i++ {
+		switch v := values[i]; {
+		case v == 0:
+			e.pen = style{}
+		case v == 1:
+			e.pen.attr |= attrBold
+		case v == 3:
+			e.pen.attr |= attrItalic
+		case v == 4:
+			e.pen.attr |= attrUnderline
+		case v == 7:
+			e.pen.attr |= attrReverse
+		case v == 9:
+			e.pen.attr |= attrStrikethrough
+		case v == 22:
+			e.pen.attr &^= attrBold
+		case v == 23:
+			e.pen.attr &^= attrItalic
+		case v == 24:
+			e.pen.attr &^= attrUnderline
+		case v == 27:
+			e.pen.attr &^= attrReverse
+		case v == 29:
+			e.pen.attr &^= attrStrikethrough
+		case v == 39:
+			e.pen.fg = color.Default
+		case v == 49:
+			e.pen.bg = color.Default
+		case v >= 30 && v <= 37:
+			e.pen.fg = color.ANSI(uint8(v - 30))
+		case v >= 40 && v <= 47:
+			e.pen.bg = color.ANSI(uint8(v - 40))
+		case v >= 90 && v <= 97:
+			e.pen.fg = color.ANSI(uint8(v - 90 + 8))
+		case v >= 100 && v <= 107:
+			e.pen.bg = color.ANSI(uint8(v - 100 + 8))
+		case v == 38 || v == 48:
+			c := color.Palette(uint8(values[i+2]))
+			if values[i+1] == 2 {
+				c = color.RGB(values[i+2], values[i+3], values[i+4])
+				i += 2
+			}
+			i += 2
+			if v == 38 {
+				e.pen.fg = c
+			} else {
+				e.pen.bg = c
+			}
+		default:
+			e.t.Fatalf("unexpected SGR parameter %d", v)
+		}
+	}
+}
+
+func (e *emulator) assertScreen(w *generalCellWriter) {
+	e.t.Helper()
+	for y := range w.rows {
+		for x, want := range w.rows[y] {
+			want.Style.hasCursor = false
+			if got := e.cells[e.origin+y][x]; got != want {
+				e.t.Fatalf("cell (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func newTestCellWriter(isAlternative bool, width, height int) (*generalCellWriter, *bytes.Buffer) {
+	output := new(bytes.Buffer)
+	w := &generalCellWriter{
+		isAlternative: isAlternative,
+		width:         width,
+		height:        height,
+		rows:          newMatrix(width, height),
+		out:           make([]byte, 0, 4096),
+		output:        output,
+		profile:       color.TrueColor,
+	}
+	w.fill(style{})
+	return w, output
+}
+
+func putString(w cellWriter, s string, x, y int, st style) {
+	for _, r := range s {
+		width := runewidth.RuneWidth(r)
+		w.put(cell{r, width, st}, x, y)
+		if width == 2 {
+			w.put(cell{' ', 0, st}, x+1, y)
+		}
+		x += width
+	}
+}
+
+func Test_generalCellWriter_draw(t *testing.T) {
+	for _, isAlternative := range []bool{true, false} {
+		t.Run(If(isAlternative, "alternative", "inline"), func(t *testing.T) {
+			w, output := newTestCellWriter(isAlternative, 20, 5)
+			e := newEmulator(t, 20, 5)
+
+			putString(w, "Hello, 世界", 1, 0, style{fg: color.RGB(29, 161, 242), attr: attrBold})
+			putString(w, "underline", 3, 2, style{attr: attrUnderline | attrReverse})
+			putString(w, "last", 16, 4, style{bg: color.Palette(0)})
+			w.draw()
+			e.write(output.Bytes())
+			e.assertScreen(w)
+
+			output.Reset()
+			putString(w, "J", 1, 0, style{fg: color.RGB(29, 161, 242), attr: attrBold})
+			putString(w, "x", 3, 0, style{fg: color.Red})
+			putString(w, "U", 3, 2, style{attr: attrUnderline})
+			w.draw()
+			e.write(output.Bytes())
+			e.assertScreen(w)
+			if strings.Contains(output.String(), "nderline") || strings.Contains(output.String(), "last") {
+				t.Errorf("unchanged cells are drawn again: %q", output.String())
+			}
+			if !isAlternative && strings.Contains(output.String(), "H") {
+				t.Errorf("absolute move is used in inline mode: %q", output.String())
+			}
+
+			output.Reset()
+			w.draw()
+			if output.Len() != 0 {
+				t.Errorf("drawing the same cells sends %q", output.String())
+			}
+		})
+	}
+}
+
+func Test_generalCellWriter_draw_oneCell(t *testing.T) {
+	w, output := newTestCellWriter(true, 80, 24)
+	w.draw()
+	output.Reset()
+	w.put(cell{'x', 1, style{}}, 40, 12)
+	w.draw()
+	if got, want := output.String(), "\x1b[13;41Hx"; got != want {
+		t.Errorf("draw() sends %q, want %q", got, want)
+	}
+}
+
+func Test_generalCellWriter_draw_allocations(t *testing.T) {
+	w, _ := newTestCellWriter(true, 80, 24)
+	w.output = new(bytes.Buffer)
+	w.draw()
+	i := 0
+	allocs := testing.AllocsPerRun(10, func() {
+		i++
+		st := style{fg: color.Palette(uint8(i)), attr: attribute(i) & attrBold}
+		for y := 0; y < 24; y++ {
+			putString(w, "The quick brown fox jumps over the lazy dog", y%10, y, st)
+		}
+		w.output.(*bytes.Buffer).Reset()
+		w.draw()
+	})
+	if allocs != 0 {
+		t.Errorf("draw() allocates %v times", allocs)
+	}
+}
+
+func Test_generalCellWriter_setHeight(t *testing.T) {
+	w, output := newTestCellWriter(false, 10, 0)
+	w.maxHeight = 5
+	e := newEmulator(t, 10, 5)
+	// The prompt is on the fourth row.
+	e.y, e.origin = 3, 3
+
+	for _, lines := range [][]string{
+		{"one"},
+		{"one", "two", "three"},
+		{"one", "two", "three", "four", "five", "six"},
+		{"1", "2"},
+	} {
+		w.setHeight(len(lines))
+		w.fill(style{})
+		for y := 0; y < w.height; y++ {
+			putString(w, lines[y], 0, y, style{})
+		}
+		w.draw()
+		e.write(output.Bytes())
+		output.Reset()
+		e.assertScreen(w)
+		for y := e.origin + w.height; y < e.height; y++ {
+			if e.cells[y][0].Char != ' ' {
+				t.Errorf("row %d below the rows is not cleared", y)
+			}
+		}
+	}
+	if e.origin != 0 {
+		t.Errorf("rows start from row %d, want 0", e.origin)
+	}
+}
+
+func Test_generalCellWriter_printLines(t *testing.T) {
+	w, output := newTestCellWriter(false, 10, 0)
+	w.maxHeight = 4
+	e := newEmulator(t, 10, 4)
+	e.y, e.origin = 1, 1
+	w.setHeight(2)
+	putString(w, "ui", 0, 0, style{})
+	w.draw()
+
+	w.printLines("step 1")
+	w.printLines("step 2\nstep 3")
+	w.fill(style{})
+	putString(w, "ui", 0, 1, style{})
+	w.draw()
+	e.write(output.Bytes())
+
+	got := make([]string, 0, e.height)
+	for _, row := range e.cells {
+		line := ""
+		for _, c := range row {
+			line += string(c.Char)
+		}
+		got = append(got, strings.TrimRight(line, " "))
+	}
+	// The terminal is scrolled by two lines for the rows below the printed lines.
+	want := []string{"step 2", "step 3", "", "ui"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("screen = %q, want %q", got, want)
+	}
+}
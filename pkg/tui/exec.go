@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+)
+
+// execProcess is a command to be run on the terminal given back temporarily.
+type execProcess struct {
+	cmd      *exec.Cmd
+	callback func(error) any
+}
+
+// ExecProcess returns a value that suspends the program to run cmd on the terminal, e.g. to open $EDITOR.
+// Return it from a handler or send it as an event.
+// The terminal is restored before cmd starts, and the views are drawn again after it exits.
+// The standard input, output and error of cmd are connected to the terminal unless they are set.
+// callback receives the error of cmd.Run, and its return value is handled like that of a handler,
+// e.g. Terminate ends the program. callback can be nil.
+// The program does not read the input while cmd is running.
+// It is not supported on Windows, with OptionIO or on a Screen without OptionExecRunner,
+// where callback receives an error without running cmd.
+func ExecProcess(cmd *exec.Cmd, callback func(error) any) any {
+	return execProcess{cmd: cmd, callback: callback}
+}
+
+// errExecNotSupported is passed to the callback of ExecProcess when the terminal cannot be given back.
+var errExecNotSupported = errors.New("ExecProcess is not supported without the terminal of the standard input")
+
+// run runs the command with the terminal connected to its standard input, output and error.
+func (e execProcess) run() error {
+	if e.cmd.Stdin == nil {
+		e.cmd.Stdin = os.Stdin
+	}
+	if e.cmd.Stdout == nil {
+		e.cmd.Stdout = os.Stdout
+	}
+	if e.cmd.Stderr == nil {
+		e.cmd.Stderr = os.Stderr
+	}
+	// Ctrl+C interrupts the command but not the program, which is in the same process group.
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+	return e.cmd.Run()
+}
+
+// finish passes the error of the command to the callback, and handles its return value.
+// It reports whether the program should terminate.
+func (e execProcess) finish(cfg *config, err error) bool {
+	if e.callback == nil {
+		return false
+	}
+	return handleResult(cfg, e.callback(err))
+}
+
+// inputGate pauses reading the input of the terminal while another process uses the terminal.
+type inputGate struct {
+	mutex     sync.Mutex
+	cond      *sync.Cond
+	isPaused  bool
+	isStopped bool
+}
+
+func newInputGate() *inputGate {
+	g := &inputGate{}
+	g.cond = sync.NewCond(&g.mutex)
+	return g
+}
+
+func (g *inputGate) pause() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.isPaused = true
+}
+
+func (g *inputGate) resume() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.isPaused = false
+	g.cond.Broadcast()
+}
+
+// errReaderStopped is returned by gatedReader after it is stopped.
+var errReaderStopped = errors.New("the input reader is stopped")
+
+// gatedReader reads the file only when it is readable and the gate is not paused,
+// so that the input for another process is not taken.
+// After stop is called, Read returns errReaderStopped instead of waiting for the file.
+type gatedReader struct {
+	file *os.File
+	gate *inputGate
+	// cancel becomes readable when the write end of the pipe, stopper, is closed.
+	cancel  *os.File
+	stopper *os.File
+}
+
+func newGatedReader(file *os.File, gate *inputGate) (*gatedReader, error) {
+	cancel, stopper, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	return &gatedReader{file: file, gate: gate, cancel: cancel, stopper: stopper}, nil
+}
+
+// stop makes a blocked Read and the following ones return errReaderStopped.
+func (r *gatedReader) stop() {
+	_ = r.stopper.Close()
+	r.gate.mutex.Lock()
+	defer r.gate.mutex.Unlock()
+	r.gate.isStopped = true
+	r.gate.cond.Broadcast()
+}
+
+// close releases the pipe. It is called after Read has returned.
+func (r *gatedReader) close() {
+	_ = r.cancel.Close()
+}
+
+func (r *gatedReader) Read(p []byte) (int, error) {
+	for {
+		err := waitReadable(r.file, r.cancel)
+		if err != nil {
+			return 0, err
+		}
+		r.gate.mutex.Lock()
+		if r.gate.isStopped {
+			r.gate.mutex.Unlock()
+			return 0, errReaderStopped
+		}
+		if !r.gate.isPaused {
+			// The file has data to read, so that Read does not block the gate.
+			n, err := r.file.Read(p)
+			r.gate.mutex.Unlock()
+			return n, err
+		}
+		for r.gate.isPaused && !r.gate.isStopped {
+			r.gate.cond.Wait()
+		}
+		r.gate.mutex.Unlock()
+	}
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExecProcess(t *testing.T) {
+	var got error
+	createView := func() *View {
+		return String("editor").KeyHandler(func(r rune) any {
+			return ExecProcess(exec.Command("editor", "file"), func(err error) any {
+				got = err
+				return Terminate
+			})
+		})
+	}
+	errEditor := errors.New("exit status 3")
+	var ran []string
+	s, err := NewScreen(createView, 10, 1, OptionExecRunner(func(cmd *exec.Cmd) error {
+		ran = cmd.Args
+		return errEditor
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SendKeys('e')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ran, []string{"editor", "file"}) {
+		t.Errorf("the runner ran %q, want the command", ran)
+	}
+	if got != errEditor {
+		t.Errorf("callback received %v, want %v", got, errEditor)
+	}
+	if !s.IsTerminated() {
+		t.Error("the result of the callback is not handled")
+	}
+
+	// The command is not run without a runner.
+	s, err = NewScreen(createView, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SendKeys('e')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != errExecNotSupported {
+		t.Errorf("callback received %v, want %v", got, errExecNotSupported)
+	}
+}
+
+func TestExecProcess_optionIO(t *testing.T) {
+	ch := make(chan any, 1)
+	var got error
+	ch <- ExecProcess(exec.Command("true"), func(err error) any {
+		got = err
+		return Terminate
+	})
+	in, inWriter := io.Pipe()
+	defer inWriter.Close()
+	err := Run(func() *View {
+		return String("editor")
+	}, OptionIO(in, io.Discard, fixedSize(10, 1), nil), OptionChannel(ch))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got != errExecNotSupported {
+		t.Errorf("callback received %v, want %v", got, errExecNotSupported)
+	}
+}
+
+func Test_gatedReader(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	gate := newInputGate()
+	reader, err := newGatedReader(r, gate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.close()
+	gate.pause()
+	read := make(chan string)
+	go func() {
+		b := make([]byte, 8)
+		n, _ := reader.Read(b)
+		read <- string(b[:n])
+	}()
+	_, _ = w.Write([]byte("a"))
+	select {
+	case s := <-read:
+		t.Fatalf("read %q while paused", s)
+	case <-time.After(50 * time.Millisecond):
+	}
+	gate.resume()
+	if s := <-read; s != "a" {
+		t.Errorf("read %q, want %q", s, "a")
+	}
+}
+
+func Test_gatedReader_stop(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	reader, err := newGatedReader(r, newInputGate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.close()
+	read := make(chan error)
+	go func() {
+		_, err := reader.Read(make([]byte, 8))
+		read <- err
+	}()
+	select {
+	case err := <-read:
+		t.Fatalf("Read() returned %v before stop", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	reader.stop()
+	if err := <-read; err != errReaderStopped {
+		t.Errorf("Read() error = %v, want %v", err, errReaderStopped)
+	}
+	// The data written after stop is left for others.
+	_, _ = w.Write([]byte("a"))
+	if _, err := reader.Read(make([]byte, 8)); err != errReaderStopped {
+		t.Errorf("Read() error = %v after stop, want %v", err, errReaderStopped)
+	}
+}
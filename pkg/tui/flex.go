@@ -0,0 +1,155 @@
+package tui
+
+// flexItem is a child of a stack along the direction of the stack.
+type flexItem struct {
+	basis  int
+	grow   int
+	shrink int
+	min    int
+	max    int // 0 means no maximum
+}
+
+func (it flexItem) clamp(size int) int {
+	if it.max > 0 && size > it.max {
+		size = it.max
+	}
+	if size < it.min {
+		size = it.min
+	}
+	if size < 0 {
+		size = 0
+	}
+	return size
+}
+
+// weight returns the share of the item in the free space, which is positive if growing.
+func (it flexItem) weight(isGrowing bool) int {
+	if isGrowing {
+		return it.grow
+	}
+	return it.shrink * it.basis
+}
+
+// flexSizes distributes the available size to the items like the flexible layout of CSS.
+// The free space is distributed in proportion to the weights, and the items whose sizes are bounded
+// by the minimum or the maximum are frozen until the others fit. Each share is rounded down
+// against the rest of the free space, so the sizes of the unbounded items sum up to the available size.
+func flexSizes(available int, items []flexItem) []int {
+	sizes := make([]int, len(items))
+	isFrozen := make([]bool, len(items))
+	free := available
+	for _, it := range items {
+		free -= it.basis
+	}
+	isGrowing := free > 0
+	for i, it := range items {
+		if it.weight(isGrowing) == 0 {
+			sizes[i] = it.clamp(it.basis)
+			isFrozen[i] = true
+		}
+	}
+	targets := make([]int, len(items))
+	for {
+		rest, total := available, 0
+		for i, it := range items {
+			if isFrozen[i] {
+				rest -= sizes[i]
+			} else {
+				rest -= it.basis
+				total += it.weight(isGrowing)
+			}
+		}
+		if total == 0 {
+			return sizes
+		}
+		violation := 0
+		for i, it := range items {
+			if isFrozen[i] {
+				continue
+			}
+			w := it.weight(isGrowing)
+			share := rest * w / total
+			rest -= share
+			total -= w
+			targets[i] = it.basis + share
+			sizes[i] = it.clamp(targets[i])
+			violation += sizes[i] - targets[i]
+		}
+		for i := range items {
+			if isFrozen[i] {
+				continue
+			}
+			switch {
+			case violation == 0,
+				violation > 0 && sizes[i] > targets[i],
+				violation < 0 && sizes[i] < targets[i]:
+				isFrozen[i] = true
+			}
+		}
+		if violation == 0 {
+			return sizes
+		}
+	}
+}
+
+// flexItemOf returns the child as an item of the stack of the direction, where size is the size
+// of the child along the direction resolved from AbsoluteSize, RelativeSize or the natural height.
+func flexItemOf(child *View, dir direction, size int) flexItem {
+	if child == nil {
+		return flexItem{}
+	}
+	it := flexItem{basis: size, min: child.minWidth, max: child.maxWidth}
+	if dir == vertical {
+		it.min, it.max = child.minHeight, child.maxHeight
+	}
+	if child.basis > 0 {
+		it.basis = child.basis
+	}
+	switch {
+	case child.isFlex:
+		it.grow, it.shrink = child.grow, child.shrink
+	case it.basis == 0:
+		// A view of no size shares the space left with the others.
+		it.grow = 1
+	}
+	return it
+}
+
+// mainSizes returns the sizes of the children along the direction of the stack.
+func mainSizes(children []*View, dir direction, sizes []int, available int) []int {
+	items := make([]flexItem, len(children))
+	for i, child := range children {
+		items[i] = flexItemOf(child, dir, sizes[i])
+	}
+	return flexSizes(available, items)
+}
+
+// clampSize bounds the size by the minimum and the maximum, where 0 means no bound.
+func clampSize(size, min, max int) int {
+	return flexItem{min: min, max: max}.clamp(size)
+}
+
+// gaps returns the total size of the gaps between the children of the stack set by Spacing.
+func gaps(v *View, children []*View) int {
+	n := 0
+	for _, child := range children {
+		if child != nil {
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return v.spacing * (n - 1)
+}
+
+// alignOffset returns the position of a child of the size in the space of the available size.
+func alignOffset(a Alignment, available, size int) int {
+	switch a {
+	case AlignLeading, AlignStretch:
+		return 0
+	case AlignTrailing:
+		return available - size
+	}
+	return (available - size) / 2
+}
@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_flexSizes(t *testing.T) {
+	tests := []struct {
+		name      string
+		available int
+		items     []flexItem
+		want      []int
+	}{
+		{
+			name:      "auto views share the space left evenly",
+			available: 10,
+			items:     []flexItem{{grow: 1}, {grow: 1}, {grow: 1}},
+			want:      []int{3, 3, 4},
+		},
+		{
+			name:      "grow in proportion",
+			available: 24,
+			items:     []flexItem{{basis: 6}, {grow: 2, shrink: 1}, {grow: 1, shrink: 1}},
+			want:      []int{6, 12, 6},
+		},
+		{
+			name:      "rounding keeps the sum",
+			available: 20,
+			items:     []flexItem{{grow: 1}, {grow: 1}, {grow: 1}, {basis: 4}},
+			want:      []int{5, 5, 6, 4},
+		},
+		{
+			name:      "grow from the basis",
+			available: 20,
+			items:     []flexItem{{basis: 10, grow: 1}, {basis: 4, grow: 1}},
+			want:      []int{13, 7},
+		},
+		{
+			name:      "maximum is kept and the rest is distributed to the others",
+			available: 30,
+			items:     []flexItem{{grow: 1, max: 5}, {grow: 1}, {grow: 1}},
+			want:      []int{5, 12, 13},
+		},
+		{
+			name:      "shrink in proportion to the basis",
+			available: 15,
+			items:     []flexItem{{basis: 10, shrink: 1}, {basis: 10, shrink: 1}},
+			want:      []int{8, 7},
+		},
+		{
+			name:      "minimum is kept when shrinking",
+			available: 12,
+			items:     []flexItem{{basis: 10, shrink: 1, min: 8}, {basis: 10, shrink: 1}},
+			want:      []int{8, 4},
+		},
+		{
+			name:      "views without shrink keep their sizes",
+			available: 12,
+			items:     []flexItem{{basis: 10}, {basis: 10, shrink: 1}, {grow: 1}},
+			want:      []int{10, 2, 0},
+		},
+		{
+			name:      "minimum of a view of no size",
+			available: 10,
+			items:     []flexItem{{grow: 1, min: 8}, {grow: 1}},
+			want:      []int{8, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flexSizes(tt.available, tt.items)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flexSizes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlex(t *testing.T) {
+	createView := func() *View {
+		return HStack(
+			String("side").AbsoluteSize(6, 0),
+			VStack(
+				String("main").Flex(1, 0),
+				String("status").AbsoluteSize(0, 1),
+			).Flex(2, 1),
+			String("info").Flex(1, 1).MinSize(7, 0).MaxSize(0, 2),
+		)
+	}
+	s, err := NewScreen(createView, 24, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The pane of the maximum height is centered vertically.
+	want := "side  main\n" +
+		"                 info\n" +
+		"\n" +
+		"      status"
+	if got := s.Text(); got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+
+	// The pane of the minimum width takes the space from the other.
+	err = s.Resize(16, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "side  mai\n" +
+		"      n  info\n" +
+		"\n" +
+		"      sta"
+	if got := s.Text(); got != want {
+		t.Errorf("resized: text = %q, want %q", got, want)
+	}
+}
+
+func TestBasis(t *testing.T) {
+	s, err := NewScreen(func() *View {
+		return HStack(
+			String("ab").Basis(4),
+			String("cd").AbsoluteSize(6, 0).Basis(3),
+			String("ef").Basis(2).Flex(1, 0),
+			String("gh"),
+		)
+	}, 14, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The basis applies without Flex, and the views of no size share the space left.
+	want := "ab  cd ef  gh"
+	if got := s.Text(); got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestAlignment(t *testing.T) {
+	tests := []struct {
+		name string
+		view func() *View
+		want string
+	}{
+		{
+			name: "HStack aligns its children vertically",
+			view: func() *View {
+				return HStack(
+					String("a").AbsoluteSize(2, 1),
+					String("b").AbsoluteSize(2, 1),
+				).Alignment(AlignTrailing)
+			},
+			want: "\n\n\na b",
+		},
+		{
+			name: "VStack aligns its children horizontally",
+			view: func() *View {
+				return VStack(
+					String("a").AbsoluteSize(2, 1),
+					String("bb").AbsoluteSize(3, 1),
+				).Alignment(AlignLeading)
+			},
+			want: "a\nbb\n\n",
+		},
+		{
+			name: "ZStack takes an anchor of both directions",
+			view: func() *View {
+				return ZStack(String("z").AbsoluteSize(1, 1)).Alignment(AlignTrailing, AlignLeading)
+			},
+			want: "         z\n\n\n",
+		},
+		{
+			name: "stretch ignores the sizes of the children",
+			view: func() *View {
+				return HStack(
+					VStack(String("a"), String("b")).AbsoluteSize(1, 1),
+					VStack(String("c"), String("d")).AbsoluteSize(1, 1).MaxSize(0, 1),
+				).Alignment(AlignStretch)
+			},
+			want: "ac\nb\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScreen(tt.view, 10, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Text(); got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpacing(t *testing.T) {
+	createView := func() *View {
+		return VStack(
+			HStack(String("a"), String("b"), nil, String("c")).Spacing(2).AbsoluteSize(0, 1),
+			String("d"),
+		).Spacing(1)
+	}
+	s, err := NewScreen(createView, 11, 3, OptionInline())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a   b   c\n\nd"
+	if got := s.Text(); got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
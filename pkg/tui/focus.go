@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
+// Focusable makes the view focusable with the ID, which identifies the view across renders.
+// When there are focusable views, one of them has the focus, which is moved by Tab and Shift+Tab
+// in layout order, or by returning Focus from a handler.
+// Keys and pastes are sent only to the focused view, its descendants and its ancestors,
+// and to the views that neither are nor contain focusable views.
+func (v *View) Focusable(id string) *View {
+	if v == nil {
+		return nil
+	}
+	v.focusID = id
+	return v
+}
+
+// Focused sets a modifier applied to the view when it has the focus, e.g. (*View).Reverse.
+func (v *View) Focused(fn func(v *View) *View) *View {
+	if v == nil {
+		return nil
+	}
+	v.focusedModifier = fn
+	return v
+}
+
+// focus moves the focus to the view of the ID.
+type focus struct {
+	id string
+}
+
+// Focus returns a value that moves the focus to the focusable view of the ID.
+// Return it from a handler or send it as an event.
+// If there is no such view, the first focusable view gets the focus.
+func Focus(id string) any {
+	return focus{id}
+}
+
+// IsFocused reports whether the focusable view of the ID has the focus in the program.
+// It must be called while the program is rendering its views, e.g. in createView of Run, and panics otherwise.
+func IsFocused(id string) bool {
+	c := currentStates("IsFocused")
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.focusedID == id
+}
+
+// resolveFocus gives the focus to the first focusable view if the focused view is not laid out.
+// It reports whether the focus has changed.
+func (cfg *config) resolveFocus() bool {
+	if cfg.focusedView() != nil {
+		return false
+	}
+	id := ""
+	if len(cfg.focusables) > 0 {
+		id = cfg.focusables[0].focusID
+	}
+	changed := cfg.focusedID != id
+	cfg.focusedID = id
+	return changed
+}
+
+func (cfg *config) focusedView() *View {
+	for _, v := range cfg.focusables {
+		if v.focusID == cfg.focusedID {
+			return v
+		}
+	}
+	return nil
+}
+
+// moveFocus moves the focus to the next focusable view in layout order, or to the previous one if delta is -1.
+func (cfg *config) moveFocus(delta int) {
+	if len(cfg.focusables) == 0 {
+		return
+	}
+	i := 0
+	for j, v := range cfg.focusables {
+		if v.focusID == cfg.focusedID {
+			i = j + delta
+			break
+		}
+	}
+	i = (i + len(cfg.focusables)) % len(cfg.focusables)
+	cfg.focusedID = cfg.focusables[i].focusID
+}
+
+// isFocusKey reports whether the key moves the focus, and the direction.
+func isFocusKey(e key.Event) (int, bool) {
+	if e.Code != key.Tab || e.Action == key.Release {
+		return 0, false
+	}
+	switch e.Mod {
+	case 0:
+		return 1, true
+	case key.Shift:
+		return -1, true
+	}
+	return 0, false
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
+func TestFocus(t *testing.T) {
+	fields := map[string]string{}
+	global := ""
+	field := func(id string) *View {
+		return Fmt("%s:%s", id, fields[id]).
+			Focusable(id).
+			Focused(func(v *View) *View {
+				return v.Bold()
+			}).
+			KeyHandler(func(r rune) any {
+				if r == key.Enter {
+					return Focus("c")
+				}
+				if r > ' ' && r <= '~' && r != 'q' {
+					fields[id] += string(r)
+					return true
+				}
+				return nil
+			})
+	}
+	s, err := NewScreen(func() *View {
+		return VStack(
+			field("a"),
+			HStack(field("b"), field("c")),
+			If(IsFocused("a"), String("focused a"), nil),
+		).KeyHandler(func(r rune) any {
+			if r != 'q' {
+				return nil
+			}
+			global += string(r)
+			return true
+		})
+	}, 20, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertText := func(want string) {
+		t.Helper()
+		if got := s.Text(); got != want {
+			t.Errorf("text = %q, want %q", got, want)
+		}
+	}
+
+	assertText("a:\nb:        c:\n\nfocused a")
+	err = s.SendKeys('x', key.Tab, 'y', key.Tab, 'z', key.Tab, 'w', key.BackTab, 'v', key.Enter, 'u', 'q')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"a": "xw", "b": "y", "c": "zvu"}; fields["a"] != want["a"] || fields["b"] != want["b"] || fields["c"] != want["c"] {
+		t.Errorf("fields = %q, want %q", fields, want)
+	}
+	// The ancestors receive the keys not handled by the focused view.
+	if global != "q" {
+		t.Errorf("global = %q, want %q", global, "q")
+	}
+	assertText("a:xw\nb:y       c:zvu\n\n")
+	if grid := s.StyleGrid(); !strings.HasPrefix(strings.Split(grid, "\n")[1], "..........aaaaa") {
+		t.Errorf("the focused view is not bold:\n%s", grid)
+	}
+
+	err = s.Send(Focus("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SendKeys('t')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields["b"] != "yt" {
+		t.Errorf("fields[b] = %q after Focus", fields["b"])
+	}
+}
+
+func TestFocus_overlay(t *testing.T) {
+	received := ""
+	isOpen := true
+	s, err := NewScreen(func() *View {
+		return ZStack(
+			VStack(
+				String("a").Focusable("a").KeyHandler(func(r rune) any {
+					received += "a"
+					return true
+				}),
+				String("b").Focusable("b"),
+			),
+			// Views without focusable views receive keys as before.
+			String("dialog").KeyHandler(func(r rune) any {
+				received += "d"
+				isOpen = false
+				return true
+			}).Priority(1).Hidden(!isOpen),
+		)
+	}, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SendKeys('x', 'x')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != "da" {
+		t.Errorf("received = %q, want %q", received, "da")
+	}
+}
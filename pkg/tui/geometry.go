@@ -13,3 +13,17 @@ type edge struct {
 	leading  int
 	trailing int
 }
+
+func (r rect) contains(x, y int) bool {
+	return x >= r.x && x < r.x+r.width && y >= r.y && y < r.y+r.height
+}
+
+func (r rect) intersect(other rect) rect {
+	x1, y1 := If(r.x > other.x, r.x, other.x), If(r.y > other.y, r.y, other.y)
+	x2 := If(r.x+r.width < other.x+other.width, r.x+r.width, other.x+other.width)
+	y2 := If(r.y+r.height < other.y+other.height, r.y+r.height, other.y+other.height)
+	if x2 < x1 || y2 < y1 {
+		return rect{x1, y1, 0, 0}
+	}
+	return rect{x1, y1, x2 - x1, y2 - y1}
+}
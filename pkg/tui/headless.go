@@ -0,0 +1,270 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
+// Screen lays out views on an in-memory terminal instead of the real one.
+// It dispatches keys and mouse events the same way as Run, so it can be used to test views without a TTY.
+// See the tuitest package for helpers built on it.
+type Screen struct {
+	w            *headlessCellWriter
+	p            *program
+	createView   func() *View
+	isTerminated bool
+}
+
+// NewScreen creates a Screen of the given size and renders the view returned by createView on it.
+func NewScreen(createView func() *View, width, height int, options ...Option) (*Screen, error) {
+	p, err := newProgram(options)
+	if err != nil {
+		return nil, err
+	}
+	s := &Screen{
+		w:          newHeadlessCellWriter(width, height),
+		p:          p,
+		createView: createView,
+	}
+	err = s.Render()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Render lays out the view again.
+func (s *Screen) Render() error {
+	return s.p.renderView(s.w, s.createView)
+}
+
+// Resize changes the size of the screen and renders the view again.
+func (s *Screen) Resize(width, height int) error {
+	s.w = newHeadlessCellWriter(width, height)
+	return s.Render()
+}
+
+// SendKeys dispatches each key to the views and renders the view again.
+func (s *Screen) SendKeys(keys ...rune) error {
+	for _, k := range keys {
+		err := s.dispatch(k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendKeyEvents dispatches each key event to the views and renders the view again.
+func (s *Screen) SendKeyEvents(events ...key.Event) error {
+	for _, e := range events {
+		err := s.dispatch(e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendMouse dispatches the mouse event, whose position is relative to the screen,
+// to the views and renders the view again.
+func (s *Screen) SendMouse(e MouseEvent) error {
+	return s.dispatch(e)
+}
+
+// SendPaste dispatches the text as pasted into the terminal and renders the view again.
+func (s *Screen) SendPaste(text string) error {
+	return s.dispatch(Paste{Text: text})
+}
+
+// SendInput decodes the input as the bytes read from a terminal,
+// and dispatches the decoded keys and mouse events.
+func (s *Screen) SendInput(input string) error {
+	buffer := []rune(input)
+	var paste pasteReader
+	for {
+		event, size := readInput(buffer, &paste)
+		if size == 0 {
+			return nil
+		}
+		buffer = buffer[size:]
+		err := s.dispatch(event)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Screen) dispatch(input any) error {
+	if s.isTerminated {
+		return errors.New("the screen is terminated")
+	}
+	s.isTerminated = dispatch(&s.p.cfg, input)
+	return s.finish()
+}
+
+// finish passes the processes of ExecProcess to the runner of OptionExecRunner,
+// or finishes them with errExecNotSupported without it, and renders the view again.
+func (s *Screen) finish() error {
+	for !s.isTerminated && len(s.p.cfg.processes) > 0 {
+		e := s.p.cfg.processes[0]
+		s.p.cfg.processes = s.p.cfg.processes[1:]
+		err := errExecNotSupported
+		if s.p.cfg.execRunner != nil {
+			err = s.p.cfg.execRunner(e.cmd)
+		}
+		s.isTerminated = e.finish(&s.p.cfg, err)
+	}
+	if s.isTerminated {
+		return nil
+	}
+	return s.Render()
+}
+
+// ExpirePendingKeys gives the pending keys of an incomplete sequence back to the handlers of single keys
+// as if they timed out. See KeySequenceHandler.
+func (s *Screen) ExpirePendingKeys() error {
+	if s.isTerminated {
+		return errors.New("the screen is terminated")
+	}
+	s.isTerminated = expirePendingKeys(&s.p.cfg)
+	return s.finish()
+}
+
+// Send passes the event to the event handler, as the events sent to the channel of OptionChannel.
+func (s *Screen) Send(event any) error {
+	if s.isTerminated {
+		return errors.New("the screen is terminated")
+	}
+	s.isTerminated = handleEvent(&s.p.cfg, event, true)
+	return s.finish()
+}
+
+// Printed returns the text of the events of Println sent by Send, one element per event.
+func (s *Screen) Printed() []string {
+	return s.p.cfg.printed
+}
+
+// Result returns the result passed to TerminateWith by a handler.
+func (s *Screen) Result() any {
+	return s.p.cfg.result
+}
+
+// IsTerminated reports whether a handler has returned Terminate.
+func (s *Screen) IsTerminated() bool {
+	return s.isTerminated
+}
+
+// Size returns the width and height of the screen.
+func (s *Screen) Size() (int, int) {
+	return s.w.size()
+}
+
+// Text returns the characters on the screen, one line per row.
+// Trailing spaces of each row are trimmed.
+func (s *Screen) Text() string {
+	var b strings.Builder
+	for y, row := range s.w.rows {
+		line := make([]rune, 0, len(row))
+		for x, c := range row {
+			if c.Width == 0 && x > 0 && row[x-1].Width == 2 {
+				continue
+			}
+			if c.Char == 0 {
+				c.Char = ' '
+			}
+			line = append(line, c.Char)
+		}
+		b.WriteString(strings.TrimRight(string(line), " "))
+		if y < len(s.w.rows)-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// StyleGrid returns the styles of the cells on the screen.
+// Each cell is represented by a symbol, '.' for the default style,
+// and the legend of the symbols follows the grid after an empty line.
+func (s *Screen) StyleGrid() string {
+	symbols := make(map[style]rune)
+	legend := make([]string, 0)
+	var b strings.Builder
+	for _, row := range s.w.rows {
+		for _, c := range row {
+			st := c.Style
+			if st == (style{}) {
+				b.WriteByte('.')
+				continue
+			}
+			symbol, ok := symbols[st]
+			if !ok {
+				symbol = styleSymbol(len(symbols))
+				symbols[st] = symbol
+				legend = append(legend, fmt.Sprintf("%c: %s", symbol, st))
+			}
+			b.WriteRune(symbol)
+		}
+		b.WriteByte('\n')
+	}
+	if len(legend) > 0 {
+		b.WriteByte('\n')
+		b.WriteString(strings.Join(legend, "\n"))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+const styleSymbols = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func styleSymbol(i int) rune {
+	if i < len(styleSymbols) {
+		return rune(styleSymbols[i])
+	}
+	// Latin-1 letters are used after ASCII ones run out.
+	return rune(0xc0 + i - len(styleSymbols))
+}
+
+type headlessCellWriter struct {
+	width     int
+	height    int
+	maxHeight int
+	rows      [][]cell
+}
+
+func newHeadlessCellWriter(width, height int) *headlessCellWriter {
+	return &headlessCellWriter{
+		width:     width,
+		height:    height,
+		maxHeight: height,
+		rows:      newMatrix(width, height),
+	}
+}
+
+func (w *headlessCellWriter) setHeight(height int) {
+	w.height = If(height > w.maxHeight, w.maxHeight, height)
+	w.rows = newMatrix(w.width, w.height)
+}
+
+func (w *headlessCellWriter) size() (int, int) {
+	return w.width, w.height
+}
+
+func (w *headlessCellWriter) matrix() [][]cell {
+	return w.rows
+}
+
+func (w *headlessCellWriter) put(c cell, x, y int) {
+	w.rows[y][x] = c
+}
+
+func (w *headlessCellWriter) fill(s style) {
+	for y := 0; y < w.height; y++ {
+		for x := 0; x < w.width; x++ {
+			w.rows[y][x] = cell{' ', 1, s}
+		}
+	}
+}
@@ -1,33 +1,139 @@
 package tui
 
 import (
+	"bytes"
+	"fmt"
 	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/dytlzl/tervi/pkg/key"
 )
 
-var stateContainer = map[uintptr]any{}
+// hookKey identifies the call site of a hook by the file, the line
+// and the index among the call sites of hooks on the line.
+// The program counter is not used as it is, because inlining gives one call site several program counters.
+type hookKey struct {
+	file  string
+	line  int
+	index int
+}
+
+// hookKeys caches the keys of the program counters of the calls of hooks.
+var hookKeys = struct {
+	sync.Mutex
+	byPC map[uintptr]hookKey
+	// counts maps the frames of the inlined functions of a call to the number of call sites of hooks
+	// with the same frames, which are on the same line.
+	counts map[string]int
+}{byPC: map[uintptr]hookKey{}, counts: map[string]int{}}
+
+// stateContainer holds the states of the hooks of a program.
+type stateContainer struct {
+	mutex       sync.Mutex
+	states      map[hookKey]any
+	focusedID   string
+	pendingKeys []key.Event
+}
+
+func newStateContainer() *stateContainer {
+	return &stateContainer{states: map[hookKey]any{}}
+}
+
+// renderingStates maps the IDs of the goroutines rendering views to the *stateContainer of their programs,
+// so that the hooks find the states of the program without serializing the programs.
+var renderingStates sync.Map
+
+// bindStates makes the hooks called on the current goroutine use the states until unbind is called.
+func bindStates(c *stateContainer) (unbind func()) {
+	id := goroutineID()
+	renderingStates.Store(id, c)
+	return func() {
+		renderingStates.Delete(id)
+	}
+}
+
+// currentStates returns the states of the program rendering its views on the current goroutine.
+// It panics if no program is rendering on the goroutine, because the hook has no program to keep its state in.
+func currentStates(hook string) *stateContainer {
+	if c, ok := renderingStates.Load(goroutineID()); ok {
+		return c.(*stateContainer)
+	}
+	panic(fmt.Sprintf("tui: %s is called outside of rendering the views of a program", hook))
+}
+
+// goroutineID returns the ID of the current goroutine, which the stack trace begins with,
+// e.g. "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// callerKey returns the key of the call site of the caller of the function skip frames above.
+// The copies of a call site inlined into several functions have different program counters
+// and different frames of the inlined functions, and are given the same index,
+// while the call sites on the same line have the same frames, and are given different indexes.
+func callerKey(skip int) hookKey {
+	var pcs [16]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	caller, more := frames.Next()
+	hookKeys.Lock()
+	defer hookKeys.Unlock()
+	if k, ok := hookKeys.byPC[caller.PC]; ok {
+		return k
+	}
+	chain := fmt.Sprintf("%s:%d", caller.File, caller.Line)
+	// The frames of inlined functions have no Func, and are followed by the frame of the function they are inlined into.
+	for f := caller; f.Func == nil && more; {
+		f, more = frames.Next()
+		chain += fmt.Sprintf(" %s:%d", f.File, f.Line)
+	}
+	k := hookKey{caller.File, caller.Line, hookKeys.counts[chain]}
+	hookKeys.counts[chain]++
+	hookKeys.byPC[caller.PC] = k
+	return k
+}
 
+// UseState returns the state kept for the call site in the program and a function to update it.
+// It must be called while the program is rendering its views, e.g. in createView of Run, and panics otherwise.
 func UseState[T any](initialState T) (T, func(T)) {
 	return useState(initialState, 2)
 }
 
 func useState[T any](initialState T, skip int) (T, func(T)) {
-	pc, _, _, _ := runtime.Caller(skip)
-	if _, ok := stateContainer[pc]; !ok {
-		stateContainer[pc] = initialState
+	k := callerKey(skip)
+	c := currentStates("UseState")
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.states[k]; !ok {
+		c.states[k] = initialState
 	}
-	return stateContainer[pc].(T), func(newState T) {
-		stateContainer[pc] = newState
+	return c.states[k].(T), func(newState T) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.states[k] = newState
 	}
 }
 
+// UseRef returns a pointer kept for the call site in the program.
+// It must be called while the program is rendering its views, e.g. in createView of Run, and panics otherwise.
 func UseRef[T any](initialState T) *T {
 	return useRef(initialState, 2)
 }
 
 func useRef[T any](initialState T, skip int) *T {
-	pc, _, _, _ := runtime.Caller(skip)
-	if _, ok := stateContainer[pc]; !ok {
-		stateContainer[pc] = &initialState
+	k := callerKey(skip)
+	c := currentStates("UseRef")
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.states[k]; !ok {
+		c.states[k] = &initialState
 	}
-	return stateContainer[pc].(*T)
+	return c.states[k].(*T)
 }
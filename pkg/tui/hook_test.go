@@ -1,25 +1,144 @@
 package tui
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 )
 
 func Test_UseRef(t *testing.T) {
-	funcWithUseRef := func() int {
+	got := 0
+	s, err := NewScreen(func() *View {
 		p := UseRef(3)
 		*p++
-		return *p
+		got = *p
+		return Fmt("%d", *p)
+	}, 5, 1)
+	if err != nil {
+		t.Fatal(err)
 	}
-	t.Run("retain the state", func(t *testing.T) {
-		got1 := funcWithUseRef()
-		want1 := 4
-		if got1 != want1 {
-			t.Errorf("useRef() got key = %v, want %v", got1, want1)
+	if got != 4 {
+		t.Errorf("UseRef() got %v, want %v", got, 4)
+	}
+	err = s.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 5 {
+		t.Errorf("UseRef() got %v after rendering again, want %v", got, 5)
+	}
+}
+
+func Test_UseRef_outsideRendering(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("UseRef() did not panic outside of rendering")
 		}
-		got2 := funcWithUseRef()
-		want2 := 5
-		if got2 != want2 {
-			t.Errorf("useRef() got key = %v, want %v", got2, want2)
+	}()
+	UseRef(0)
+}
+
+func Test_UseRef_sameLine(t *testing.T) {
+	s, err := NewScreen(func() *View {
+		a, b := UseRef(0), UseRef(10)
+		*a++
+		*b++
+		return Fmt("%d %d", *a, *b)
+	}, 5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Text(), "2 12"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func Test_UseRef_programs(t *testing.T) {
+	createView := func() *View {
+		count := UseRef(0)
+		*count++
+		return Fmt("%d", *count)
+	}
+	var wg sync.WaitGroup
+	screens := make([]*Screen, 4)
+	for i := range screens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s, err := NewScreen(createView, 5, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for j := 0; j < i; j++ {
+				err = s.Render()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			screens[i] = s
+		}(i)
+	}
+	wg.Wait()
+	for i, s := range screens {
+		if s == nil {
+			continue
+		}
+		if got, want := s.Text(), fmt.Sprint(i+1); got != want {
+			t.Errorf("screen %d shows %q, want %q", i, got, want)
+		}
+	}
+}
+
+func Test_UseRef_renderingConcurrently(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking, err := NewScreen(func() *View {
+		count := UseRef(0)
+		*count++
+		if *count == 2 {
+			close(entered)
+			<-release
 		}
-	})
+		return Fmt("%d", *count)
+	}, 5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered := make(chan error)
+	go func() {
+		rendered <- blocking.Render()
+	}()
+	<-entered
+	created := make(chan *Screen)
+	go func() {
+		s, err := NewScreen(func() *View {
+			return Fmt("%d", *UseRef(10))
+		}, 5, 1)
+		if err != nil {
+			t.Error(err)
+		}
+		created <- s
+	}()
+	select {
+	case s := <-created:
+		if s != nil && s.Text() != "10" {
+			t.Errorf("the other screen shows %q, want %q", s.Text(), "10")
+		}
+	case <-time.After(time.Second):
+		t.Error("a program waits for another program to render")
+	}
+	close(release)
+	if err := <-rendered; err != nil {
+		t.Fatal(err)
+	}
+	if got, want := blocking.Text(), "2"; got != want {
+		t.Errorf("the blocked screen shows %q, want %q", got, want)
+	}
 }
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package tui
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// canExec reports whether ExecProcess can pause reading the input.
+const canExec = true
+
+// waitReadable blocks until the file has data to read or cancel becomes readable.
+// It returns errReaderStopped in the latter case.
+func waitReadable(f, cancel *os.File) error {
+	fd, cancelFd := int(f.Fd()), int(cancel.Fd())
+	for {
+		var set unix.FdSet
+		set.Set(fd)
+		set.Set(cancelFd)
+		_, err := unix.Select(If(fd > cancelFd, fd, cancelFd)+1, &set, nil, nil, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err == nil && set.IsSet(cancelFd) {
+			return errReaderStopped
+		}
+		return err
+	}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package tui
+
+import (
+	"os"
+)
+
+// canExec reports whether ExecProcess can pause reading the input.
+const canExec = false
+
+// waitReadable returns immediately, and the read blocks instead.
+func waitReadable(f, cancel *os.File) error {
+	return nil
+}
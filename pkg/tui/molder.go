@@ -8,6 +8,12 @@ import (
 )
 
 func moldView(r cellWriter, v *View, cfg *config, frame rect, parentFrame rect, defaultStyle style, allowOverflow bool) error {
+	if v.focusID != "" {
+		cfg.focusables = append(cfg.focusables, v)
+		if v.focusID == cfg.focusedID && v.focusedModifier != nil {
+			v.focusedModifier(v)
+		}
+	}
 	vr, err := newMolder(
 		r,
 		frame,
@@ -22,7 +28,7 @@ func moldView(r cellWriter, v *View, cfg *config, frame rect, parentFrame rect,
 		v.style = new(style)
 	}
 	v.style.merge(defaultStyle)
-	if v.border != nil || v.title != "" || v.content != nil || v.style.b256 != 0 {
+	if v.border != nil || v.title != "" || v.content != nil || !v.style.bg.IsDefault() {
 		vr.fill(cell{' ', 1, *v.style})
 	}
 	if v.border != nil {
@@ -35,8 +41,12 @@ func moldView(r cellWriter, v *View, cfg *config, frame rect, parentFrame rect,
 	if v.content != nil {
 		vr.moldBody(v.content(), *v.style)
 	}
-	if v.keyHandler != nil {
-		cfg.viewPQ.PushView(v)
+	if v.keyHandler != nil || v.keyCaptureHandler != nil || v.sequenceHandler != nil || v.pasteHandler != nil {
+		cfg.keyViews = append(cfg.keyViews, v)
+	}
+	v.frame = frame
+	if v.hasMouseHandler() {
+		cfg.mouseViews = append(cfg.mouseViews, v)
 	}
 
 	if v.children == nil {
@@ -46,11 +56,6 @@ func moldView(r cellWriter, v *View, cfg *config, frame rect, parentFrame rect,
 	availableWidth := frame.width - int(v.paddingLeading) - int(v.paddingTrailing)
 	availableHeight := frame.height - int(v.paddingTop) - int(v.paddingBottom)
 
-	remainedWidth := availableWidth
-	remainedHeight := availableHeight
-	numberOfAutoWidth := 0
-	numberOfAutoHeight := 0
-
 	children := v.children()
 
 	for idx := range children {
@@ -61,35 +66,48 @@ func moldView(r cellWriter, v *View, cfg *config, frame rect, parentFrame rect,
 		if children[idx].style == nil {
 			children[idx].style = new(style)
 		}
+		// stretch the children across the direction of the stack
+		if v.alignX == AlignStretch && v.dir != horizontal {
+			children[idx].absoluteWidth = availableWidth
+		}
+		if v.alignY == AlignStretch && v.dir != vertical {
+			children[idx].absoluteHeight = availableHeight
+		}
 		// calculate absolute size from relative size
 		if children[idx].absoluteWidth == 0 {
 			children[idx].absoluteWidth = availableWidth * int(children[idx].relativeWidth) / 12
 		}
-		if children[idx].absoluteHeight == 0 && v.dir == vertical && children[idx].content != nil {
+		// In inline mode, the height of the whole view is the sum of the natural heights of the children.
+		if children[idx].absoluteHeight == 0 && v.dir == vertical && (children[idx].content != nil || cfg.isNaturalHeight) {
 			if children[idx].absoluteWidth == 0 {
-				children[idx].absoluteWidth = availableWidth
+				children[idx].absoluteWidth = clampSize(availableWidth, children[idx].minWidth, children[idx].maxWidth)
 			}
-			children[idx].absoluteHeight = heightFromWidth(
-				children[idx].content(),
-				children[idx].absoluteWidth-int(children[idx].paddingLeading)-int(children[idx].paddingTrailing),
-			) +
-				int(children[idx].paddingTop) +
-				int(children[idx].paddingBottom)
+			children[idx].absoluteHeight = naturalHeight(children[idx], children[idx].absoluteWidth)
 		}
 
 		if children[idx].absoluteHeight == 0 {
 			children[idx].absoluteHeight = availableHeight * int(children[idx].relativeHeight) / 12
 		}
+	}
 
-		remainedWidth -= children[idx].absoluteWidth
-		remainedHeight -= children[idx].absoluteHeight
-
-		// count auto-sizing view
-		if children[idx].absoluteWidth == 0 {
-			numberOfAutoWidth++
+	// distribute the space along the direction of the stack
+	if v.dir == horizontal || v.dir == vertical {
+		sizes := make([]int, len(children))
+		for idx, child := range children {
+			if child != nil {
+				sizes[idx] = If(v.dir == horizontal, child.absoluteWidth, child.absoluteHeight)
+			}
 		}
-		if children[idx].absoluteHeight == 0 {
-			numberOfAutoHeight++
+		available := If(v.dir == horizontal, availableWidth, availableHeight) - gaps(v, children)
+		for idx, size := range mainSizes(children, v.dir, sizes, available) {
+			if children[idx] == nil {
+				continue
+			}
+			if v.dir == horizontal {
+				children[idx].absoluteWidth = size
+			} else {
+				children[idx].absoluteHeight = size
+			}
 		}
 	}
 
@@ -101,31 +119,25 @@ func moldView(r cellWriter, v *View, cfg *config, frame rect, parentFrame rect,
 			continue
 		}
 
-		// calculate size of auto-sizing view
-		if child.absoluteWidth == 0 {
-			if v.dir == horizontal {
-				child.absoluteWidth = remainedWidth / numberOfAutoWidth
-				numberOfAutoWidth--
-				remainedWidth -= child.absoluteWidth
-			} else {
+		// fill the other direction of the stack
+		if v.dir != horizontal {
+			if child.absoluteWidth == 0 {
 				child.absoluteWidth = availableWidth
 			}
+			child.absoluteWidth = clampSize(child.absoluteWidth, child.minWidth, child.maxWidth)
 		}
-		if child.absoluteHeight == 0 {
-			if v.dir == vertical {
-				child.absoluteHeight = remainedHeight / numberOfAutoHeight
-				numberOfAutoHeight--
-				remainedHeight -= child.absoluteHeight
-			} else {
+		if v.dir != vertical {
+			if child.absoluteHeight == 0 {
 				child.absoluteHeight = availableHeight
 			}
+			child.absoluteHeight = clampSize(child.absoluteHeight, child.minHeight, child.maxHeight)
 		}
 
-		x := frame.x + int(v.paddingLeading) + (availableWidth-child.absoluteWidth)/2
+		x := frame.x + int(v.paddingLeading) + alignOffset(v.alignX, availableWidth, child.absoluteWidth)
 		if v.dir == horizontal {
 			x = accumulatedX
 		}
-		y := frame.y + int(v.paddingTop) + (availableHeight-child.absoluteHeight)/2
+		y := frame.y + int(v.paddingTop) + alignOffset(v.alignY, availableHeight, child.absoluteHeight)
 		if v.dir == vertical {
 			y = accumulatedY
 		}
@@ -134,29 +146,36 @@ func moldView(r cellWriter, v *View, cfg *config, frame rect, parentFrame rect,
 			break
 		}
 
+		childParentFrame := rect{
+			frame.x + int(v.paddingLeading),
+			frame.y + int(v.paddingTop),
+			v.absoluteWidth - int(v.paddingLeading) - int(v.paddingTrailing),
+			v.absoluteHeight - int(v.paddingTop) - int(v.paddingBottom),
+		}
+		childFrame := rect{
+			x,
+			y,
+			child.absoluteWidth,
+			child.absoluteHeight,
+		}
+		child.clip = childFrame.intersect(v.clip).intersect(childParentFrame)
+		child.parent = v
 		err = moldView(r, child, cfg,
-			rect{
-				x,
-				y,
-				child.absoluteWidth,
-				child.absoluteHeight,
-			},
-			rect{
-				frame.x + int(v.paddingLeading),
-				frame.y + int(v.paddingTop),
-				v.absoluteWidth - int(v.paddingLeading) - int(v.paddingTrailing),
-				v.absoluteHeight - int(v.paddingTop) - int(v.paddingBottom),
-			},
+			childFrame,
+			childParentFrame,
 			*v.style,
 			allowOverflow || v.allowOverflow)
 		if err != nil {
 			return err
 		}
+		if child.focusID != "" || child.containsFocusable {
+			v.containsFocusable = true
+		}
 		if v.dir == horizontal {
-			accumulatedX += child.absoluteWidth
+			accumulatedX += child.absoluteWidth + v.spacing
 		}
 		if v.dir == vertical {
-			accumulatedY += child.absoluteHeight
+			accumulatedY += child.absoluteHeight + v.spacing
 		}
 	}
 	return nil
@@ -310,6 +329,68 @@ func RuneWidth(r rune) int {
 	return runewidth.RuneWidth(r)
 }
 
+// naturalHeight returns the height that the view needs to show everything at the width.
+// Vertical stacks need the sum of the heights of the children, and the other views need the largest one.
+func naturalHeight(v *View, width int) int {
+	if v == nil {
+		return 0
+	}
+	if v.absoluteHeight > 0 {
+		return v.absoluteHeight
+	}
+	if v.style == nil {
+		v.style = new(style)
+	}
+	innerWidth := width - int(v.paddingLeading) - int(v.paddingTrailing)
+	height := 0
+	switch {
+	case v.content != nil:
+		height = heightFromWidth(v.content(), innerWidth)
+	case v.children != nil:
+		children := v.children()
+		widths := childWidths(v, children, innerWidth)
+		for i, child := range children {
+			h := naturalHeight(child, widths[i])
+			if v.dir == vertical {
+				height += h
+			} else if h > height {
+				height = h
+			}
+		}
+		if v.dir == vertical {
+			height += gaps(v, children)
+		}
+	}
+	return clampSize(height+int(v.paddingTop)+int(v.paddingBottom), v.minHeight, v.maxHeight)
+}
+
+// childWidths returns the widths of the children in the same way as moldView.
+func childWidths(v *View, children []*View, availableWidth int) []int {
+	widths := make([]int, len(children))
+	for i, child := range children {
+		if child == nil {
+			continue
+		}
+		widths[i] = child.absoluteWidth
+		if widths[i] == 0 {
+			widths[i] = availableWidth * int(child.relativeWidth) / 12
+		}
+	}
+	if v.dir == horizontal {
+		return mainSizes(children, horizontal, widths, availableWidth-gaps(v, children))
+	}
+	for i, child := range children {
+		if child == nil {
+			continue
+		}
+		if widths[i] == 0 || v.alignX == AlignStretch {
+			widths[i] = availableWidth
+		}
+		widths[i] = clampSize(widths[i], child.minWidth, child.maxWidth)
+	}
+	return widths
+}
+
 func heightFromWidth(slice []text, width int) int {
 	x, y := 0, 0
 	for _, as := range slice {
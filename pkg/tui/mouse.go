@@ -0,0 +1,132 @@
+package tui
+
+// MouseEvent is a mouse event reported by the terminal.
+// X and Y are relative to the frame of the view that receives the event,
+// or to the terminal when the event handler of OptionEventHandler receives it.
+type MouseEvent struct {
+	X      int
+	Y      int
+	Button MouseButton
+	Action MouseAction
+	Shift  bool
+	Alt    bool
+	Ctrl   bool
+}
+
+type MouseButton int
+
+const (
+	MouseNone MouseButton = iota
+	MouseLeft
+	MouseMiddle
+	MouseRight
+	MouseWheelUp
+	MouseWheelDown
+	MouseWheelLeft
+	MouseWheelRight
+)
+
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota + 1
+	MouseRelease
+	MouseDrag
+)
+
+// IsWheel reports whether the event is caused by a mouse wheel.
+func (e MouseEvent) IsWheel() bool {
+	return e.Button >= MouseWheelUp
+}
+
+// readMouse reads a mouse event in the SGR encoding (ESC [ < Cb ; Cx ; Cy M|m)
+// from the head of the buffer.
+func readMouse(buffer []rune) (MouseEvent, int, bool) {
+	if len(buffer) < 3 || buffer[0] != 0x1b || buffer[1] != '[' || buffer[2] != '<' {
+		return MouseEvent{}, 0, false
+	}
+	params := [3]int{}
+	i := 0
+	for n := 3; n < len(buffer); n++ {
+		switch r := buffer[n]; {
+		case r >= '0' && r <= '9':
+			params[i] = params[i]*10 + int(r-'0')
+		case r == ';' && i < 2:
+			i++
+		case (r == 'M' || r == 'm') && i == 2:
+			return decodeMouse(params[0], params[1], params[2], r == 'm'), n + 1, true
+		default:
+			return MouseEvent{}, 0, false
+		}
+	}
+	return MouseEvent{}, 0, false
+}
+
+func decodeMouse(code, x, y int, isRelease bool) MouseEvent {
+	e := MouseEvent{
+		X:     x - 1,
+		Y:     y - 1,
+		Shift: code&4 != 0,
+		Alt:   code&8 != 0,
+		Ctrl:  code&16 != 0,
+	}
+	button := code & 3
+	switch {
+	case code&64 != 0:
+		e.Button = MouseWheelUp + MouseButton(button)
+	case button == 3:
+		e.Button = MouseNone
+	default:
+		e.Button = MouseLeft + MouseButton(button)
+	}
+	switch {
+	case isRelease:
+		e.Action = MouseRelease
+	case code&32 != 0:
+		e.Action = MouseDrag
+	default:
+		e.Action = MousePress
+	}
+	return e
+}
+
+// dispatchMouse sends the event to the views containing the pointer from the topmost one
+// until one of them handles it, and then to the event handler if none of them did.
+// It reports whether the program should terminate.
+func dispatchMouse(cfg *config, e MouseEvent) bool {
+	for i := len(cfg.mouseViews) - 1; i >= 0; i-- {
+		v := cfg.mouseViews[i]
+		if !v.clip.contains(e.X, e.Y) {
+			continue
+		}
+		local := e
+		local.X -= v.frame.x
+		local.Y -= v.frame.y
+		if result := v.handleMouse(local); result != nil {
+			return handleResult(cfg, result)
+		}
+	}
+	if cfg.eventHandler != nil {
+		return handleResult(cfg, cfg.eventHandler(e))
+	}
+	return false
+}
+
+func (v *View) handleMouse(e MouseEvent) any {
+	if v.mouseHandler != nil {
+		if result := v.mouseHandler(e); result != nil {
+			return result
+		}
+	}
+	switch {
+	case v.clickHandler != nil && e.Button == MouseLeft && e.Action == MousePress:
+		return v.clickHandler(e)
+	case v.scrollHandler != nil && e.IsWheel():
+		return v.scrollHandler(e)
+	}
+	return nil
+}
+
+func (v *View) hasMouseHandler() bool {
+	return v.mouseHandler != nil || v.clickHandler != nil || v.scrollHandler != nil
+}
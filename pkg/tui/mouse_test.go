@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"testing"
+)
+
+func Test_readMouse(t *testing.T) {
+	tests := []struct {
+		name       string
+		buffer     string
+		want       MouseEvent
+		wantLength int
+		wantOK     bool
+	}{
+		{
+			name:       "press of the left button",
+			buffer:     "\x1b[<0;10;5M",
+			want:       MouseEvent{X: 9, Y: 4, Button: MouseLeft, Action: MousePress},
+			wantLength: 10,
+			wantOK:     true,
+		},
+		{
+			name:       "release of the right button",
+			buffer:     "\x1b[<2;1;1mabc",
+			want:       MouseEvent{X: 0, Y: 0, Button: MouseRight, Action: MouseRelease},
+			wantLength: 9,
+			wantOK:     true,
+		},
+		{
+			name:       "drag with the left button and Ctrl",
+			buffer:     "\x1b[<48;3;4M",
+			want:       MouseEvent{X: 2, Y: 3, Button: MouseLeft, Action: MouseDrag, Ctrl: true},
+			wantLength: 10,
+			wantOK:     true,
+		},
+		{
+			name:       "wheel down with Shift",
+			buffer:     "\x1b[<69;7;8M",
+			want:       MouseEvent{X: 6, Y: 7, Button: MouseWheelDown, Action: MousePress, Shift: true},
+			wantLength: 10,
+			wantOK:     true,
+		},
+		{
+			name:   "incomplete",
+			buffer: "\x1b[<0;10",
+		},
+		{
+			name:   "arrow key",
+			buffer: "\x1b[A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotLength, gotOK := readMouse([]rune(tt.buffer))
+			if got != tt.want || gotLength != tt.wantLength || gotOK != tt.wantOK {
+				t.Errorf("readMouse() = %+v, %v, %v, want %+v, %v, %v", got, gotLength, gotOK, tt.want, tt.wantLength, tt.wantOK)
+			}
+			if gotIncomplete := isIncomplete([]rune(tt.buffer)); gotIncomplete != (tt.name == "incomplete") {
+				t.Errorf("isIncomplete() = %v", gotIncomplete)
+			}
+		})
+	}
+}
+
+func Test_dispatchMouse(t *testing.T) {
+	var clicked []string
+	var position MouseEvent
+	onClick := func(name string, result any) func(MouseEvent) any {
+		return func(e MouseEvent) any {
+			clicked = append(clicked, name)
+			position = e
+			return result
+		}
+	}
+	s, err := NewScreen(func() *View {
+		return ZStack(
+			VStack(
+				String("top").AbsoluteSize(0, 1).OnClick(onClick("top", true)),
+				VStack(String("bottom")).OnClick(onClick("bottom", true)),
+			).OnClick(onClick("stack", true)),
+			String("overlay").AbsoluteSize(4, 2).OnClick(onClick("overlay", nil)),
+		)
+	}, 10, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		x, y        int
+		wantClicked []string
+		wantX       int
+		wantY       int
+	}{
+		{name: "the view under the pointer", x: 1, y: 0, wantClicked: []string{"top"}, wantX: 1, wantY: 0},
+		{name: "the position relative to the view", x: 5, y: 4, wantClicked: []string{"bottom"}, wantX: 5, wantY: 3},
+		{name: "unhandled by the topmost view", x: 4, y: 2, wantClicked: []string{"overlay", "bottom"}, wantX: 4, wantY: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clicked = nil
+			err := s.SendMouse(MouseEvent{X: tt.x, Y: tt.y, Button: MouseLeft, Action: MousePress})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(clicked) != len(tt.wantClicked) {
+				t.Fatalf("clicked = %v, want %v", clicked, tt.wantClicked)
+			}
+			for i := range clicked {
+				if clicked[i] != tt.wantClicked[i] {
+					t.Fatalf("clicked = %v, want %v", clicked, tt.wantClicked)
+				}
+			}
+			if position.X != tt.wantX || position.Y != tt.wantY {
+				t.Errorf("position = (%d, %d), want (%d, %d)", position.X, position.Y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestList_click(t *testing.T) {
+	selected := 0
+	s, err := NewScreen(func() *View {
+		return List(&selected, String("a"), String("b"), String("c")).Spacing(1).Border()
+	}, 10, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The items are below the border, and separated by the spacing.
+	tests := []struct {
+		y    int
+		want int
+	}{
+		{y: 4, want: 1},
+		{y: 3, want: 1},
+		{y: 6, want: 2},
+		{y: 2, want: 0},
+		{y: 1, want: 0},
+	}
+	for _, tt := range tests {
+		err := s.SendMouse(MouseEvent{X: 2, Y: tt.y, Button: MouseLeft, Action: MousePress})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if selected != tt.want {
+			t.Errorf("click at row %d: selected = %d, want %d", tt.y, selected, tt.want)
+		}
+	}
+}
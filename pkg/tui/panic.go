@@ -0,0 +1,37 @@
+package tui
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// PanicError is returned by Run when a view or a handler panics.
+// The terminal is restored before it is returned.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the value of the panic if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func newPanicError(value any) *PanicError {
+	stack := make([]byte, 64<<10)
+	stack = stack[:runtime.Stack(stack, false)]
+	return &PanicError{Value: value, Stack: stack}
+}
+
+// recoverPanic turns a panic into a PanicError assigned to err.
+// It must be deferred directly.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = newPanicError(r)
+	}
+}
@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func panicWith(value any) (err error) {
+	defer recoverPanic(&err)
+	panic(value)
+}
+
+func Test_recoverPanic(t *testing.T) {
+	err := panicWith("boom")
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want *PanicError", err)
+	}
+	if pe.Value != "boom" {
+		t.Errorf("Value = %v, want boom", pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "panicWith") {
+		t.Errorf("Stack does not contain the function that panicked:\n%s", pe.Stack)
+	}
+	if !strings.HasPrefix(err.Error(), "panic: boom\n\n") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	err = panicWith(io.ErrUnexpectedEOF)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("err = %v, want to wrap %v", err, io.ErrUnexpectedEOF)
+	}
+}
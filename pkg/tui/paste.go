@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
+// Paste is the text pasted into the terminal in bracketed paste mode.
+// Line breaks in the text are normalized to "\n".
+// If the end of a paste does not arrive in time, the text received so far is read as a paste up to Ctrl+C.
+type Paste struct {
+	Text string
+}
+
+const (
+	pasteStart = "\x1b[200~"
+	pasteEnd   = "\x1b[201~"
+)
+
+// pasteReader reads pastes from the head of a buffer that grows while the rest of a paste arrives.
+// It keeps where the search for the end of the paste stopped, so that each rune of a long paste is searched once.
+type pasteReader struct {
+	// next is the index of the buffer where the search for the end of the paste at its head resumes.
+	next int
+}
+
+// read reads a paste (ESC [ 200 ~ text ESC [ 201 ~) from the head of the buffer.
+// It returns 0 as the size if the end of the paste has not been read yet.
+// The buffer must keep the runes given before until the paste is read.
+func (r *pasteReader) read(buffer []rune) (Paste, int, bool) {
+	if !hasPrefix(buffer, pasteStart) {
+		return Paste{}, 0, false
+	}
+	for n := If(r.next > len(pasteStart), r.next, len(pasteStart)); n <= len(buffer)-len(pasteEnd); n++ {
+		if hasPrefix(buffer[n:], pasteEnd) {
+			r.next = 0
+			return newPaste(buffer[len(pasteStart):n]), n + len(pasteEnd), true
+		}
+		r.next = n + 1
+	}
+	return Paste{}, 0, true
+}
+
+func newPaste(text []rune) Paste {
+	s := strings.ReplaceAll(string(text), "\r\n", "\n")
+	return Paste{Text: strings.ReplaceAll(s, "\r", "\n")}
+}
+
+// pasteTimeout is how long to wait for the rest of a paste while no input arrives
+// before reading the text received so far as the paste.
+const pasteTimeout = 500 * time.Millisecond
+
+// maxPasteSize is the number of runes of a paste buffered at most while its end is not read.
+const maxPasteSize = 1 << 20
+
+// isIncomplete reports whether the buffer starts with a paste whose end has not been read yet.
+func (r *pasteReader) isIncomplete(buffer []rune) bool {
+	_, size, ok := r.read(buffer)
+	return ok && size == 0
+}
+
+// readIncomplete reads the text of a paste whose end has not been read, because it timed out
+// or is too large. The text ends before Ctrl+C, so that Ctrl+C is read as a key even if the end never comes.
+// It returns nil as the input if the text is empty.
+func (r *pasteReader) readIncomplete(buffer []rune) (any, int) {
+	if !r.isIncomplete(buffer) {
+		return nil, 0
+	}
+	r.next = 0
+	n := len(buffer)
+	for i := len(pasteStart); i < n; i++ {
+		if buffer[i] == key.CtrlC {
+			n = i
+			break
+		}
+	}
+	if n == len(pasteStart) {
+		return nil, n
+	}
+	return newPaste(buffer[len(pasteStart):n]), n
+}
+
+func hasPrefix(buffer []rune, prefix string) bool {
+	if len(buffer) < len(prefix) {
+		return false
+	}
+	for i := 0; i < len(prefix); i++ {
+		if buffer[i] != rune(prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// dispatchPaste sends the paste to the views with a paste handler in the order of bubbleTargets
+// until one of them handles it, and then to the event handler if none of them did.
+// If the event handler does not handle it either, the characters are sent as keys.
+// It reports whether the program should terminate.
+func dispatchPaste(cfg *config, p Paste) bool {
+	for _, v := range bubbleTargets(cfg) {
+		if v.pasteHandler == nil {
+			continue
+		}
+		if result := v.pasteHandler(p.Text); result != nil {
+			return handleResult(cfg, result)
+		}
+	}
+	if cfg.eventHandler != nil {
+		if result := cfg.eventHandler(p); result != nil {
+			return handleResult(cfg, result)
+		}
+	}
+	for _, r := range p.Text {
+		if r == '\n' {
+			r = key.Enter
+		}
+		if dispatchKey(cfg, key.FromRune(r)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Program is a handle to a program, which can be controlled from other goroutines while it is running.
+type Program struct {
+	createView func() *View
+	options    []Option
+	events     chan any
+	quit       chan struct{}
+	quitOnce   sync.Once
+	done       chan struct{}
+	isStarted  int32
+	err        error
+	result     any
+	debug      *debugWriter
+	logger     *log.Logger
+}
+
+// NewProgram creates a program that runs the view returned by createView like Run.
+func NewProgram(createView func() *View, options ...Option) *Program {
+	debug := &debugWriter{}
+	return &Program{
+		createView: createView,
+		options:    options,
+		events:     make(chan any),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+		debug:      debug,
+		logger:     log.New(debug, "", log.LstdFlags),
+	}
+}
+
+// Run runs the program until a handler returns Terminate, Ctrl+C is pressed, the input reaches EOF,
+// Quit is called or ctx is done. It returns the error of ctx if ctx is done.
+// The terminal is restored in all the cases. A program can be run only once.
+func (p *Program) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&p.isStarted, 0, 1) {
+		return errors.New("the program has already been run")
+	}
+	defer close(p.done)
+	prog, err := newProgram(p.options)
+	if err != nil {
+		p.err = err
+		return err
+	}
+	prog.debug = p.debug
+	p.err = prog.run(ctx, p.createView, p.events, p.quit)
+	p.result = prog.cfg.result
+	return p.err
+}
+
+// Send passes the event to the program like the channel of OptionChannel,
+// e.g. to the event handler of OptionEventHandler, and renders the view again.
+// It blocks until the running program receives the event, so it must not be called from handlers,
+// and it returns without sending the event after the program has ended.
+func (p *Program) Send(event any) {
+	select {
+	case p.events <- event:
+	case <-p.done:
+	}
+}
+
+// Println prints the operands above the views in inline mode. See the function Println.
+func (p *Program) Println(a ...any) {
+	p.Send(Println(a...))
+}
+
+// Printf prints the formatted text above the views in inline mode. See the function Printf.
+func (p *Program) Printf(format string, a ...any) {
+	p.Send(Printf(format, a...))
+}
+
+// Quit ends the program. It does not block, and can be called from handlers or before Run.
+func (p *Program) Quit() {
+	p.quitOnce.Do(func() {
+		close(p.quit)
+	})
+}
+
+// Logger returns a logger that writes to the debug server of cmd/debug while the program is running,
+// and discards the logs otherwise. Apps should log with it instead of the log package,
+// because the logs written to the terminal break the views.
+func (p *Program) Logger() *log.Logger {
+	return p.logger
+}
+
+// Wait blocks until Run returns, and returns its error.
+func (p *Program) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// Result returns the result passed to TerminateWith, or nil if the program ended otherwise.
+// It is valid after Run returns.
+func (p *Program) Result() any {
+	select {
+	case <-p.done:
+		return p.result
+	default:
+		return nil
+	}
+}
+
+// program holds the state of a program run by Run or Screen,
+// so that several programs can run in one process.
+type program struct {
+	cfg         config
+	states      *stateContainer
+	debug       *debugWriter
+	benchmarker *Benchmarker
+}
+
+func newProgram(options []Option) (*program, error) {
+	p := &program{
+		cfg:    config{},
+		states: newStateContainer(),
+		debug:  &debugWriter{},
+	}
+	for _, opt := range options {
+		err := opt(&p.cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.cfg.suspend && p.cfg.output != nil {
+		return nil, errors.New("OptionSuspend cannot be used with OptionIO")
+	}
+	return p, nil
+}
+
+// debugWriter writes to the connection to the debug server while the program is running,
+// and discards the output otherwise.
+type debugWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (d *debugWriter) set(w io.Writer) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.w = w
+}
+
+func (d *debugWriter) Write(b []byte) (int, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.w == nil {
+		return len(b), nil
+	}
+	return d.w.Write(b)
+}
+
+// renderView lays out the view returned by createView onto the whole area of w,
+// which is resized to the natural height of the view in inline mode.
+// The view is laid out again if the focused view is not found in it,
+// so that the view is created knowing the focused view.
+func (p *program) renderView(w cellWriter, createView func() *View) error {
+	unbind := bindStates(p.states)
+	defer unbind()
+	err := p.layOut(w, createView)
+	if err != nil || !p.cfg.resolveFocus() {
+		return err
+	}
+	return p.layOut(w, createView)
+}
+
+func (p *program) layOut(w cellWriter, createView func() *View) error {
+	p.states.mutex.Lock()
+	p.states.focusedID = p.cfg.focusedID
+	p.states.pendingKeys = p.cfg.pendingKeys
+	p.states.mutex.Unlock()
+	v := ZStack(createView())
+	p.cfg.isNaturalHeight = false
+	if p.cfg.inline {
+		width, _ := w.size()
+		height := naturalHeight(v, width)
+		w.setHeight(height)
+		_, h := w.size()
+		// The views are laid out in the usual way if they do not fit in the terminal.
+		p.cfg.isNaturalHeight = height == h
+	}
+	w.fill(style{})
+	width, height := w.size()
+	v.AbsoluteSize(width, height)
+	v.clip = rect{0, 0, width, height}
+	p.cfg.keyViews = p.cfg.keyViews[:0]
+	p.cfg.mouseViews = p.cfg.mouseViews[:0]
+	p.cfg.focusables = p.cfg.focusables[:0]
+	err := moldView(w, v, &p.cfg, rect{0, 0, width, height}, rect{0, 0, width, height}, style{}, false)
+	if err != nil {
+		return fmt.Errorf("failed to render view: %w", err)
+	}
+	return nil
+}
+
+type Benchmarker struct {
+	buffer    string
+	startTime time.Time
+	lastTime  time.Time
+	logger    *log.Logger
+}
+
+func (b *Benchmarker) start() {
+	if b == nil {
+		return
+	}
+	b.buffer = ""
+	b.startTime = time.Now()
+	b.lastTime = b.startTime
+}
+
+func (b *Benchmarker) benchmark(phase string) {
+	if b == nil {
+		return
+	}
+	b.buffer += fmt.Sprintf("%s: %5dμs; ", phase, time.Since(b.lastTime).Microseconds())
+	b.lastTime = time.Now()
+}
+
+func (b *Benchmarker) log() {
+	if b == nil {
+		return
+	}
+	message := fmt.Sprintf("%stotal: %5dμs", b.buffer, time.Since(b.startTime).Microseconds())
+	b.buffer = ""
+	go b.logger.Println(message)
+}
@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"sort"
+)
+
+// Keys and pastes propagate through the views in two phases like events of DOM.
+// In the capture phase, they are sent to the capture handlers from the outermost view,
+// and in the bubble phase, to the key handlers and the paste handlers from the innermost view,
+// through the view with the focus and its ancestors before the other views.
+// A view of Priority and the views inside it form an overlay, which receives them in both phases
+// before the views of lower priority, e.g. a dialog shown over the other views.
+// A handler returning nil lets them propagate further, and the others stop the propagation.
+
+// keyLayer is an overlay of Priority, or the views outside of the overlays.
+type keyLayer struct {
+	capture []*View // views to receive keys in the capture phase in order
+	bubble  []*View // views to receive keys and pastes in the bubble phase in order
+}
+
+// keyLayers returns the layers of the views to receive keys in order.
+func keyLayers(cfg *config) []keyLayer {
+	views := keyReceivers(cfg)
+	var overlays []*View
+	members := map[*View][]*View{}
+	for _, v := range views {
+		o := overlayOf(v)
+		if _, ok := members[o]; !ok {
+			overlays = append(overlays, o)
+		}
+		members[o] = append(members[o], v)
+	}
+	sort.SliceStable(overlays, func(i, j int) bool {
+		return priorityOf(overlays[i]) > priorityOf(overlays[j])
+	})
+	focused := cfg.focusedView()
+	layers := make([]keyLayer, 0, len(overlays))
+	for _, o := range overlays {
+		layers = append(layers, newKeyLayer(members[o], focused))
+	}
+	return layers
+}
+
+// newKeyLayer orders the views of a layer given in layout order along the tree of the views,
+// where the branch of the focused view comes first among siblings.
+func newKeyLayer(views []*View, focused *View) keyLayer {
+	isMember := make(map[*View]bool, len(views))
+	for _, v := range views {
+		isMember[v] = true
+	}
+	// children maps the views to the nearest views of the layer below them, and nil to the outermost ones.
+	children := map[*View][]*View{}
+	for _, v := range views {
+		u := v.parent
+		for u != nil && !isMember[u] {
+			u = u.parent
+		}
+		children[u] = append(children[u], v)
+	}
+	l := keyLayer{
+		capture: make([]*View, 0, len(views)),
+		bubble:  make([]*View, 0, len(views)),
+	}
+	var walk func(siblings []*View)
+	walk = func(siblings []*View) {
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return isAncestor(siblings[i], focused) && !isAncestor(siblings[j], focused)
+		})
+		for _, v := range siblings {
+			l.capture = append(l.capture, v)
+			walk(children[v])
+			l.bubble = append(l.bubble, v)
+		}
+	}
+	walk(children[nil])
+	return l
+}
+
+// bubbleTargets returns the views of all the layers in the order of the bubble phase,
+// which receive pastes and the keys of pending sequences.
+func bubbleTargets(cfg *config) []*View {
+	var views []*View
+	for _, l := range keyLayers(cfg) {
+		views = append(views, l.bubble...)
+	}
+	return views
+}
+
+// overlayOf returns the nearest view of Priority that contains the view, or nil if there is none.
+func overlayOf(v *View) *View {
+	for ; v != nil; v = v.parent {
+		if v.priority != 0 {
+			return v
+		}
+	}
+	return nil
+}
+
+// priorityOf returns the priority of the overlay, where nil is the views outside of the overlays.
+func priorityOf(overlay *View) int8 {
+	if overlay == nil {
+		return 0
+	}
+	return overlay.priority
+}
+
+// keyReceivers returns the views with handlers of keys or pastes that receive them in layout order.
+// When there are focusable views, they are limited by the focus. See Focusable.
+func keyReceivers(cfg *config) []*View {
+	views := make([]*View, 0, len(cfg.keyViews))
+	focused := cfg.focusedView()
+	for _, v := range cfg.keyViews {
+		if len(cfg.focusables) == 0 || receivesKeys(v, focused) {
+			views = append(views, v)
+		}
+	}
+	return views
+}
+
+// receivesKeys reports whether the view receives keys while the focused view has the focus.
+func receivesKeys(v, focused *View) bool {
+	if isAncestor(v, focused) {
+		return true
+	}
+	if v.containsFocusable {
+		return false
+	}
+	// The views inside a focusable view receive keys only when it has the focus.
+	for u := v; u != nil; u = u.parent {
+		if u.focusID != "" {
+			return u == focused
+		}
+	}
+	return true
+}
+
+// isAncestor reports whether v is u or an ancestor of u.
+func isAncestor(v, u *View) bool {
+	for ; u != nil; u = u.parent {
+		if u == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDispatchKey_propagation(t *testing.T) {
+	calls := []string{}
+	handler := func(name string, stopAt rune) func(rune) any {
+		return func(r rune) any {
+			calls = append(calls, name)
+			if r == stopAt {
+				return true
+			}
+			return nil
+		}
+	}
+	eventHandler := OptionEventHandler(func(event any) any {
+		calls = append(calls, "event handler")
+		return nil
+	})
+	s, err := NewScreen(func() *View {
+		return VStack(
+			HStack(
+				String("child").
+					KeyCaptureHandler(handler("child capture", 0)).
+					KeyHandler(handler("child", 'c')),
+			).
+				KeyCaptureHandler(handler("parent capture", 'p')).
+				KeyHandler(handler("parent", 0)),
+			String("dialog").KeyHandler(handler("dialog", 0)).Priority(1),
+		).KeyHandler(handler("root", 0))
+	}, 10, 2, eventHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  rune
+		want string
+	}{
+		{'x', "dialog, parent capture, child capture, child, parent, root, event handler"},
+		{'c', "dialog, parent capture, child capture, child"},
+		{'p', "dialog, parent capture"},
+	}
+	for _, tt := range tests {
+		calls = calls[:0]
+		err = s.SendKeys(tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(calls, ", "); got != tt.want {
+			t.Errorf("%c: calls = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestDispatchKey_overlay(t *testing.T) {
+	calls := []string{}
+	handler := func(name string) func(rune) any {
+		return func(r rune) any {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	s, err := NewScreen(func() *View {
+		return ZStack(
+			VStack(
+				String("a").KeyHandler(handler("a")),
+				String("b").KeyHandler(handler("b")),
+			).KeyHandler(handler("base")),
+			VStack(
+				String("child").KeyHandler(handler("child")),
+				String("sibling").KeyHandler(handler("sibling")),
+			).KeyHandler(handler("dialog")).Priority(1),
+			String("top").KeyHandler(handler("top")).Priority(2),
+		)
+	}, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SendKeys('x')
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The views inside an overlay receive the key before it, and the overlays before the others.
+	want := "top, child, sibling, dialog, a, b, base"
+	if got := strings.Join(calls, ", "); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+func TestDispatchKey_focusedFirst(t *testing.T) {
+	calls := []string{}
+	handler := func(name string) func(rune) any {
+		return func(r rune) any {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	s, err := NewScreen(func() *View {
+		return VStack(
+			String("status").KeyHandler(handler("status")),
+			HStack(
+				String("a").Focusable("a").KeyHandler(handler("a")),
+				String("b").Focusable("b").KeyHandler(handler("b")),
+			).KeyHandler(handler("fields")),
+		).KeyHandler(handler("root"))
+	}, 10, 2, OptionEventHandler(func(any) any { return true }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Send(Focus("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SendKeys('x')
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "b, fields, status, root"
+	if got := strings.Join(calls, ", "); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package tui
+
+import (
+	"time"
+)
+
+// notifyResize returns a channel that receives a value periodically,
+// because Windows has no signal for resizing a console.
+func notifyResize() (<-chan struct{}, func()) {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	resized := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case resized <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return resized, func() {
+		ticker.Stop()
+		close(done)
+	}
+}
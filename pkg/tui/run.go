@@ -2,209 +2,533 @@ package tui
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
+	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/dytlzl/tervi/internal/debug"
 	"github.com/dytlzl/tervi/pkg/key"
 )
 
-func Run(createView func() *View, options ...option) error {
-	cfg := config{
-		viewPQ: newQueue(),
-	}
-	for _, opt := range options {
-		err := opt(&cfg)
-		if err != nil {
-			return err
-		}
-	}
+// Run lays out the view returned by createView on the terminal and dispatches the input to it
+// until a handler returns Terminate, Ctrl+C is pressed or the input reaches EOF.
+// If a view or a handler panics, the terminal is restored and the panic is returned as a *PanicError.
+// The terminal is also restored when the process receives SIGTERM or SIGHUP, and an error is returned.
+// See NewProgram to control the program from other goroutines.
+func Run(createView func() *View, options ...Option) error {
+	return NewProgram(createView, options...).Run(context.Background())
+}
+
+// run runs the program until it terminates, the context is done or quit is closed.
+// The events received from events are handled like those from the channel of OptionChannel.
+func (p *program) run(ctx context.Context, createView func() *View, events <-chan any, quit <-chan struct{}) (err error) {
+	defer recoverPanic(&err)
+	cfg := &p.cfg
 
-	isAlternative := true
+	isAlternative := !cfg.inline
 
 	conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", debug.UDP_PORT))
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
-	log.SetOutput(conn)
-	benchmarker = new(Benchmarker)
+	p.debug.set(conn)
+	defer p.debug.set(nil)
+	if cfg.debug {
+		p.benchmarker = &Benchmarker{logger: log.New(p.debug, "", log.LstdFlags)}
+	}
 
-	w, err := newGeneralCellWriter(isAlternative)
+	w, err := newGeneralCellWriter(isAlternative, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to init renderer: %w", err)
 	}
+	w.benchmarker = p.benchmarker
+	if cfg.colorProfile != nil {
+		w.profile = *cfg.colorProfile
+	} else {
+		w.profile = DetectColorProfile(os.Getenv)
+	}
+	isMouseEnabled := cfg.mouse && isAlternative
+	w.enableBracketedPaste()
+	if isMouseEnabled {
+		w.enableMouse()
+	}
+	w.flush()
 	defer func() {
-		w.close(isAlternative)
-		for _, line := range bufferForDebug {
-			fmt.Println(line)
+		if isMouseEnabled {
+			w.disableMouse()
 		}
-	}()
-	var shouldTerminate = false
-
-	go func() {
-		for event := range cfg.channel {
-			if event == Terminate {
-				shouldTerminate = true
-			}
-			w.eventChan <- event
+		w.disableBracketedPaste()
+		if len(cfg.printed) > 0 {
+			// Print the lines sent before terminating above the last frame.
+			printPending(w, cfg)
+			w.draw()
 		}
+		w.close(isAlternative)
 	}()
 
 	keyChannel := make(chan rune, 1024)
 	keyBuffer := make([]rune, 0)
+	var paste pasteReader
+	// readErr is set before keyChannel is closed.
+	var readErr error
+	gate := newInputGate()
+	// stopped is closed when Run returns, so that the reader does not wait for keyChannel.
+	stopped := make(chan struct{})
+	readerDone := make(chan struct{})
+	var input io.Reader = os.Stdin
+	// stopReading makes the reader below return and waits for it, so that it does not take the input after Run returns.
+	// The input given by OptionIO cannot be interrupted, and the reader returns after its next Read.
+	stopReading := func() {}
+	if cfg.input != nil {
+		input = cfg.input
+	} else if canExec {
+		r, err := newGatedReader(os.Stdin, gate)
+		if err != nil {
+			return fmt.Errorf("failed to read keyboard input: %w", err)
+		}
+		defer r.close()
+		input = r
+		stopReading = func() {
+			r.stop()
+			<-readerDone
+		}
+	}
 	go func() {
-		reader := bufio.NewReaderSize(os.Stdin, 256)
+		defer close(readerDone)
+		defer close(keyChannel)
+		defer recoverPanic(&readErr)
+		reader := bufio.NewReaderSize(input, 256)
 		for {
 			ch, _, err := reader.ReadRune()
 			if err != nil {
-				panic(fmt.Errorf("failed to read keyboard input: %w", err))
+				readErr = err
+				return
+			}
+			select {
+			case keyChannel <- ch:
+			case <-stopped:
+				return
 			}
-			keyChannel <- ch
 		}
 	}()
+	defer func() {
+		close(stopped)
+		stopReading()
+	}()
+	isInputClosed := false
+
+	resized := cfg.resized
+	// continued receives SIGCONT after the process is stopped by Ctrl+Z or by a signal.
+	var continued <-chan os.Signal
+	var terminated chan os.Signal
+	if cfg.output == nil {
+		var stopResize, stopContinue func()
+		resized, stopResize = notifyResize()
+		defer stopResize()
+		continued, stopContinue = notifyContinue()
+		defer stopContinue()
+		terminated = make(chan os.Signal, 1)
+		signal.Notify(terminated, terminationSignals...)
+		defer signal.Stop(terminated)
+	}
+
+	// escapeTimer fires when an incomplete escape sequence should be read as it is.
+	escapeTimer := time.NewTimer(0)
+	if !escapeTimer.Stop() {
+		<-escapeTimer.C
+	}
+	// pasteTimer fires when a paste without its end should be read as it is.
+	pasteTimer := time.NewTimer(0)
+	if !pasteTimer.Stop() {
+		<-pasteTimer.C
+	}
+	// keyTimer fires when the keys of an incomplete sequence should be given back. See KeySequenceHandler.
+	keyTimer := time.NewTimer(0)
+	if !keyTimer.Stop() {
+		<-keyTimer.C
+	}
+
+	_, err = w.updateTerminalSize()
+	if err != nil {
+		return fmt.Errorf("failed to get terminal size: %w", err)
+	}
+
+	// handOver restores the terminal, calls fn while the input is not read, and takes the terminal back.
+	handOver := func(fn func()) error {
+		gate.pause()
+		defer gate.resume()
+		if isMouseEnabled {
+			w.disableMouse()
+		}
+		w.disableBracketedPaste()
+		err := w.close(isAlternative)
+		if err != nil {
+			return err
+		}
+		fn()
+		err = w.resume()
+		if err != nil {
+			return err
+		}
+		w.enableBracketedPaste()
+		if isMouseEnabled {
+			w.enableMouse()
+		}
+		w.flush()
+		_, err = w.updateTerminalSize()
+		return err
+	}
 
+	shouldRender := true
 	for {
-		benchmarker.start()
-		shouldSkipRendering := false
-	Depth1:
-		for {
-			select {
-			case k := <-keyChannel:
-				keyBuffer = append(keyBuffer, k)
-			case <-time.After(time.Microsecond * 100):
-				break Depth1
+		for len(cfg.processes) > 0 {
+			e := cfg.processes[0]
+			cfg.processes = cfg.processes[1:]
+			processErr := errExecNotSupported
+			if canExec && w.ttyin != nil {
+				err = handOver(func() {
+					processErr = e.run()
+				})
+				if err != nil {
+					return fmt.Errorf("failed to resume: %w", err)
+				}
+			}
+			if e.finish(cfg, processErr) {
+				return nil
 			}
+			shouldRender = true
 		}
-		if len(keyBuffer) == 0 {
-			shouldSkipRendering = true
+
+		if shouldRender {
+			p.benchmarker.start()
+
+			// Print lines above the views
+			printPending(w, cfg)
+
+			// Render views
+			err = p.renderView(w, createView)
+			if err != nil {
+				return err
+			}
+			p.benchmarker.benchmark("render")
+
+			// Draw
+			w.draw()
+
+			p.benchmarker.log()
+			shouldRender = false
 		}
-		for {
-			ch, size := readBuffer(keyBuffer)
-			if size == 0 {
+
+		if isInputClosed {
+			var pe *PanicError
+			switch {
+			case errors.Is(readErr, io.EOF):
+				return nil
+			case errors.As(readErr, &pe):
+				return pe
+			}
+			return fmt.Errorf("failed to read keyboard input: %w", readErr)
+		}
+
+		// Block until something happens.
+		isEscapeExpired, isPasteExpired := false, false
+		select {
+		case k, ok := <-keyChannel:
+			if !ok {
+				isInputClosed, keyChannel = true, nil
 				break
 			}
-			keyBuffer = keyBuffer[size:]
-			if ch == key.CtrlC {
+			keyBuffer = append(keyBuffer, k)
+		case event, ok := <-cfg.channel:
+			if handleEvent(cfg, event, ok) {
 				return nil
 			}
-			handled := false
-			pq := newQueue()
-			for _, v := range cfg.viewPQ {
-				pq.PushView(v)
+			shouldRender = true
+		case event := <-events:
+			if handleEvent(cfg, event, true) {
+				return nil
 			}
-		Depth2:
-			for pq.Len() > 0 {
-				v := pq.PopView()
-				switch v.keyHandler(ch).(type) {
-				case terminate:
-					return nil
-				case nil:
-				default:
-					handled = true
-					break Depth2
-				}
+			shouldRender = true
+		case <-quit:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case sig := <-terminated:
+			return fmt.Errorf("received signal: %v", sig)
+		case <-continued:
+			// The terminal may have been changed while the process was stopped.
+			err = w.resume()
+			if err != nil {
+				return fmt.Errorf("failed to resume: %w", err)
 			}
-			if cfg.eventHandler != nil && !handled {
-				switch cfg.eventHandler(ch).(type) {
-				case terminate:
-					return nil
-				}
+			_, err = w.updateTerminalSize()
+			if err != nil {
+				return fmt.Errorf("failed to get terminal size: %w", err)
 			}
-		}
-		event := func() any {
-			select {
-			case event := <-w.eventChan:
-				shouldSkipRendering = false
-				return event
-			case <-time.After(time.Microsecond):
-				return nil
+			shouldRender = true
+		case <-resized:
+			changed, err := w.updateTerminalSize()
+			if err != nil {
+				return fmt.Errorf("failed to get terminal size: %w", err)
 			}
-		}()
-
-		if cfg.eventHandler != nil {
-			switch cfg.eventHandler(event).(type) {
-			case terminate:
+			shouldRender = shouldRender || changed
+		case <-escapeTimer.C:
+			isEscapeExpired = true
+		case <-pasteTimer.C:
+			isPasteExpired = true
+		case <-keyTimer.C:
+			if expirePendingKeys(cfg) {
 				return nil
 			}
+			shouldRender = true
 		}
 
-		if changed, _ := w.updateTerminalSize(); changed {
-			shouldSkipRendering = false
-		}
-
-		if shouldTerminate {
-			return nil
+		// Take everything else that is ready, so that a burst of input is rendered only once.
+	Drain:
+		for {
+			select {
+			case k, ok := <-keyChannel:
+				if !ok {
+					isInputClosed, keyChannel = true, nil
+					continue
+				}
+				keyBuffer = append(keyBuffer, k)
+			case event, ok := <-cfg.channel:
+				if handleEvent(cfg, event, ok) {
+					return nil
+				}
+				shouldRender = true
+			case event := <-events:
+				if handleEvent(cfg, event, true) {
+					return nil
+				}
+				shouldRender = true
+			default:
+				break Drain
+			}
 		}
 
-		if shouldSkipRendering {
-			continue
+		for {
+			if isIncomplete(keyBuffer) && !isEscapeExpired && !isInputClosed {
+				if !escapeTimer.Stop() {
+					select {
+					case <-escapeTimer.C:
+					default:
+					}
+				}
+				escapeTimer.Reset(escapeTimeout)
+				break
+			}
+			input, size := readInput(keyBuffer, &paste)
+			if size == 0 && (isPasteExpired || len(keyBuffer) >= maxPasteSize) {
+				input, size = paste.readIncomplete(keyBuffer)
+				isPasteExpired = false
+			}
+			if size == 0 {
+				if paste.isIncomplete(keyBuffer) {
+					if !pasteTimer.Stop() {
+						select {
+						case <-pasteTimer.C:
+						default:
+						}
+					}
+					pasteTimer.Reset(pasteTimeout)
+				}
+				break
+			}
+			keyBuffer = keyBuffer[size:]
+			shouldRender = true
+			if e, ok := input.(key.Event); ok && e.Mod == key.Ctrl && e.Action != key.Release {
+				if e.Code == 'c' && !cfg.dispatchCtrlC {
+					return nil
+				}
+				if e.Code == 'z' && cfg.suspend && continued != nil && w.ttyin != nil {
+					err = handOver(func() {
+						if stopProcess() == nil {
+							<-continued
+						}
+					})
+					if err != nil {
+						return fmt.Errorf("failed to resume: %w", err)
+					}
+					continue
+				}
+			}
+			if dispatch(cfg, input) {
+				return nil
+			}
+			if len(cfg.pendingKeys) > 0 {
+				if !keyTimer.Stop() {
+					select {
+					case <-keyTimer.C:
+					default:
+					}
+				}
+				keyTimer.Reset(cfg.keyTimeoutOrDefault())
+			}
 		}
+	}
+}
 
-		benchmarker.benchmark("event")
+// handleEvent passes an event received from the channel of OptionChannel to the event handler.
+// It reports whether the program should terminate.
+func handleEvent(cfg *config, event any, ok bool) bool {
+	if !ok {
+		// A closed channel would be always ready.
+		cfg.channel = nil
+		return false
+	}
+	switch event.(type) {
+	case terminate, printLine, execProcess, focus:
+		return handleResult(cfg, event)
+	}
+	if cfg.eventHandler != nil {
+		return handleResult(cfg, cfg.eventHandler(event))
+	}
+	return false
+}
 
-		// Clear
-		w.fill(style{})
-		benchmarker.benchmark("fill")
+// handleResult takes the effect of the value returned by a handler,
+// such as Terminate, Println, ExecProcess and Focus.
+// It reports whether the program should terminate.
+func handleResult(cfg *config, result any) bool {
+	switch typed := result.(type) {
+	case terminate:
+		cfg.result = typed.result
+		return true
+	case printLine:
+		cfg.printed = append(cfg.printed, typed.text)
+	case execProcess:
+		cfg.processes = append(cfg.processes, typed)
+	case focus:
+		cfg.focusedID = typed.id
+	}
+	return false
+}
 
-		v := ZStack(createView()).AbsoluteSize(w.width, w.height)
-		benchmarker.benchmark("createView")
+// printPending prints the text sent by Println since the last frame.
+func printPending(w *generalCellWriter, cfg *config) {
+	if len(cfg.printed) == 0 {
+		return
+	}
+	w.printLines(strings.Join(cfg.printed, "\n"))
+	cfg.printed = cfg.printed[:0]
+}
 
-		// Render views
-		cfg.viewPQ = newQueue()
-		err = moldView(w, v, &cfg, rect{0, 0, w.width, w.height}, rect{0, 0, w.width, w.height}, style{}, false)
-		if err != nil {
-			return fmt.Errorf("failed to render view: %w", err)
+// dispatch sends a key, a mouse event or a paste to the views.
+// A key is either a key.Event or a rune in the encoding of the key package.
+// It reports whether the program should terminate.
+func dispatch(cfg *config, input any) bool {
+	switch typed := input.(type) {
+	case rune:
+		return dispatch(cfg, key.FromRune(typed))
+	case key.Event:
+		if typed.Code == key.Unknown {
+			return false
 		}
-		benchmarker.benchmark("moldView")
-
-		// Draw
-		w.draw()
+		return dispatchKey(cfg, typed)
+	case MouseEvent:
+		return dispatchMouse(cfg, typed)
+	case Paste:
+		return dispatchPaste(cfg, typed)
+	}
+	return false
+}
 
-		benchmarker.log()
+// dispatchKey sends the key to the handlers of sequences with the pending keys if any, and otherwise to handleKey.
+// It reports whether the program should terminate.
+func dispatchKey(cfg *config, e key.Event) bool {
+	if e.Action == key.Release {
+		return handleKey(cfg, e, false)
+	}
+	if len(cfg.pendingKeys) > 0 {
+		return dispatchSequence(cfg, e)
+	}
+	return handleKey(cfg, e, true)
+}
 
+// handleKey sends the key to the capture handlers and then to the key handlers of the views
+// of each layer until one of them handles it. See keyLayers for the order.
+// If withSequences is true, the handler of sequences of a view receives the key before its key handler,
+// and the key is kept pending if it begins a sequence.
+// If none of them did, Tab and Shift+Tab move the focus, and the other keys are sent to the event handler.
+// The event handler receives the key as a rune, and does not receive releases.
+// It reports whether the program should terminate.
+func handleKey(cfg *config, e key.Event, withSequences bool) bool {
+	for _, l := range keyLayers(cfg) {
+		for _, v := range l.capture {
+			if v.keyCaptureHandler == nil {
+				continue
+			}
+			if result := v.keyCaptureHandler(e); result != nil {
+				return handleResult(cfg, result)
+			}
+		}
+		for _, v := range l.bubble {
+			if withSequences && v.sequenceHandler != nil {
+				switch result := v.sequenceHandler([]key.Event{e}); result.(type) {
+				case nil:
+				case keyPending:
+					cfg.pendingKeys = []key.Event{e}
+					return false
+				default:
+					return handleResult(cfg, result)
+				}
+			}
+			if v.keyHandler == nil {
+				continue
+			}
+			if result := v.keyHandler(e); result != nil {
+				return handleResult(cfg, result)
+			}
+		}
+	}
+	if delta, ok := isFocusKey(e); ok && len(cfg.focusables) > 0 {
+		cfg.moveFocus(delta)
+		return false
 	}
+	if cfg.eventHandler != nil && e.Action != key.Release {
+		return handleResult(cfg, cfg.eventHandler(e.Legacy()))
+	}
+	return false
 }
 
-type terminate struct{}
+type terminate struct {
+	result any
+}
 
+// Terminate ends the program when it is returned from a handler or sent as an event.
 var Terminate = terminate{}
 
-var bufferForDebug = make([]string, 0)
-
-var debugMode = false
-
-func debugf(format string, a ...any) {
-	bufferForDebug = append(bufferForDebug, fmt.Sprintf(format, a...))
+// TerminateWith returns a value that ends the program like Terminate,
+// and makes result the result of the program returned by Program.Result.
+func TerminateWith(result any) any {
+	return terminate{result}
 }
 
-type Benchmarker struct {
-	buffer    string
-	startTime time.Time
-	lastTime  time.Time
+// printLine is the text printed above the views in inline mode.
+type printLine struct {
+	text string
 }
 
-var benchmarker *Benchmarker
-
-func (b *Benchmarker) start() {
-	b.buffer = ""
-	b.startTime = time.Now()
-	b.lastTime = b.startTime
+// Println returns a value that prints the operands above the views in inline mode,
+// formatted like fmt.Println. Return it from a handler, send it to the channel of OptionChannel,
+// or use Program.Println.
+// The printed lines stay in the scrollback of the terminal, and the views are drawn again below them.
+// Nothing is printed on the alternate screen.
+func Println(a ...any) any {
+	return printLine{strings.TrimSuffix(fmt.Sprintln(a...), "\n")}
 }
 
-func (b *Benchmarker) benchmark(phase string) {
-	b.buffer += fmt.Sprintf("%s: %5dμs; ", phase, time.Since(b.lastTime).Microseconds())
-	b.lastTime = time.Now()
-}
-
-func (b *Benchmarker) log() {
-	if !debugMode {
-		return
-	}
-	message := fmt.Sprintf("%stotal: %5dμs", b.buffer, time.Since(b.startTime).Microseconds())
-	b.buffer = ""
-	go log.Println(message)
+// Printf is like Println but formats the text like fmt.Printf.
+func Printf(format string, a ...any) any {
+	return printLine{strings.TrimSuffix(fmt.Sprintf(format, a...), "\n")}
 }
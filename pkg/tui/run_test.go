@@ -0,0 +1,326 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dytlzl/tervi/internal/debug"
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
+// fixedSize returns the getSize of OptionIO for a terminal that is never resized.
+func fixedSize(width, height int) func() (int, int, error) {
+	return func() (int, int, error) {
+		return width, height, nil
+	}
+}
+
+func TestRun_optionIO(t *testing.T) {
+	pressed := ""
+	createView := func() *View {
+		return String("pressed: " + pressed).KeyHandler(func(r rune) any {
+			if r == 'p' {
+				panic("boom")
+			}
+			pressed += string(r)
+			return true
+		})
+	}
+
+	out := new(bytes.Buffer)
+	err := Run(createView, OptionIO(strings.NewReader("ab"), out, fixedSize(20, 3), nil))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if pressed != "ab" {
+		t.Errorf("pressed = %q, want %q", pressed, "ab")
+	}
+	if !strings.Contains(out.String(), "pressed:") || !strings.Contains(out.String(), "ab") {
+		t.Errorf("output does not contain the last frame: %q", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "\x1b[?1049l\x1b[u") {
+		t.Errorf("the terminal is not restored: %q", out.String())
+	}
+
+	out.Reset()
+	err = Run(createView, OptionIO(strings.NewReader("p"), out, fixedSize(20, 3), nil))
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("Run() error = %v, want the panic", err)
+	}
+	if !strings.HasSuffix(out.String(), "\x1b[?1049l\x1b[u") {
+		t.Errorf("the terminal is not restored: %q", out.String())
+	}
+}
+
+func TestRun_concurrent(t *testing.T) {
+	// Each program types the next key after rendering the count of its own state,
+	// and ends the input when the count reaches 3.
+	type result struct {
+		rendered int // the count in the last frame
+		typed    int // the number of keys handled
+	}
+	var wg sync.WaitGroup
+	results := make([]result, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(r *result) {
+			defer wg.Done()
+			in, inWriter := io.Pipe()
+			err := Run(func() *View {
+				count, setCount := UseState(0)
+				r.rendered = count
+				if count < 3 {
+					go func() { _, _ = inWriter.Write([]byte("a")) }()
+				} else {
+					inWriter.Close()
+				}
+				return Fmt("%d", count).KeyHandler(func(rune) any {
+					r.typed++
+					setCount(count + 1)
+					return true
+				})
+			}, OptionIO(in, io.Discard, fixedSize(10, 1), nil))
+			if err != nil {
+				t.Error(err)
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+	for i, r := range results {
+		if r.rendered != 3 || r.typed != 3 {
+			t.Errorf("program %d rendered %d in the last frame after %d keys, want 3 after 3", i, r.rendered, r.typed)
+		}
+	}
+}
+
+func TestRun_println(t *testing.T) {
+	ch := make(chan any, 3)
+	ch <- Println("step", 1)
+	ch <- Printf("step %d\n", 2)
+	ch <- Terminate
+	in, inWriter := io.Pipe()
+	defer inWriter.Close()
+	out := new(bytes.Buffer)
+	err := Run(func() *View {
+		return String("building")
+	}, OptionIO(in, out, fixedSize(20, 5), nil), OptionInline(), OptionChannel(ch))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "\x1b[0m\x1b[Jstep 1\r\nstep 2\r\n") {
+		t.Errorf("the lines are not printed: %q", out.String())
+	}
+}
+
+func TestProgram(t *testing.T) {
+	newProgram := func(options ...Option) (*Program, *io.PipeWriter) {
+		in, inWriter := io.Pipe()
+		t.Cleanup(func() { inWriter.Close() })
+		received := ""
+		p := NewProgram(func() *View {
+			return String("received: " + received).KeyHandler(func(r rune) any {
+				return TerminateWith(received)
+			})
+		}, append(options, OptionIO(in, io.Discard, fixedSize(20, 3), nil), OptionEventHandler(func(event any) any {
+			received += event.(string)
+			return true
+		}))...)
+		return p, inWriter
+	}
+
+	p, in := newProgram()
+	go func() {
+		p.Send("a")
+		p.Send("b")
+		_, _ = in.Write([]byte("q"))
+	}()
+	err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if p.Result() != "ab" {
+		t.Errorf("Result() = %v, want %q", p.Result(), "ab")
+	}
+	if err := p.Run(context.Background()); err == nil {
+		t.Error("Run() succeeds twice")
+	}
+	// Send does not block after the program has ended.
+	p.Send("c")
+
+	p, _ = newProgram()
+	p.Quit()
+	go func() {
+		_ = p.Run(context.Background())
+	}()
+	if err := p.Wait(); err != nil {
+		t.Errorf("Wait() error = %v after Quit", err)
+	}
+	if p.Result() != nil {
+		t.Errorf("Result() = %v after Quit, want nil", p.Result())
+	}
+
+	p, _ = newProgram()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		p.Send("a")
+		cancel()
+	}()
+	if err := p.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestProgram_Logger(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: debug.UDP_PORT})
+	if err != nil {
+		t.Skipf("the port of the debug server is not available: %v", err)
+	}
+	defer conn.Close()
+	var p *Program
+	p = NewProgram(func() *View {
+		return String("").KeyHandler(func(r rune) any {
+			p.Logger().Printf("pressed %c", r)
+			return true
+		})
+	}, OptionIO(strings.NewReader("a"), io.Discard, fixedSize(20, 3), nil))
+	// The logs are discarded while the program is not running.
+	p.Logger().Print("before")
+	err = p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	p.Logger().Print("after")
+	buf := make([]byte, 1024)
+	err = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); !strings.HasSuffix(got, "pressed a\n") {
+		t.Errorf("the debug server received %q, want the log of the key", got)
+	}
+}
+
+func TestRun_optionSuspend(t *testing.T) {
+	err := Run(func() *View {
+		return String("")
+	}, OptionIO(strings.NewReader(""), io.Discard, fixedSize(20, 3), nil), OptionSuspend())
+	if err == nil {
+		t.Error("OptionSuspend with OptionIO: expected an error")
+	}
+}
+
+func TestRun_optionDispatchCtrlC(t *testing.T) {
+	pressed := []string{}
+	createView := func() *View {
+		return String("").KeyEventHandler(func(e key.Event) any {
+			pressed = append(pressed, e.String())
+			return true
+		})
+	}
+	err := Run(createView, OptionIO(strings.NewReader("\x03a"), io.Discard, fixedSize(20, 3), nil), OptionDispatchCtrlC())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if strings.Join(pressed, " ") != "ctrl+c a" {
+		t.Errorf("pressed = %q, want %q", pressed, []string{"ctrl+c", "a"})
+	}
+
+	pressed = pressed[:0]
+	err = Run(createView, OptionIO(strings.NewReader("\x03a"), io.Discard, fixedSize(20, 3), nil))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(pressed) != 0 {
+		t.Errorf("pressed = %q after Ctrl+C", pressed)
+	}
+}
+
+func TestRun_optionKeyTimeout(t *testing.T) {
+	inputReader, inputWriter := io.Pipe()
+	pressed := make(chan string, 1)
+	createView := func() *View {
+		return String("").KeySequenceHandler(func(keys []key.Event) any {
+			if keys[0].Code == 'g' {
+				return If[any](len(keys) == 1, KeyPending, nil)
+			}
+			return nil
+		}).KeyEventHandler(func(e key.Event) any {
+			pressed <- e.String()
+			return true
+		})
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(createView, OptionIO(inputReader, io.Discard, fixedSize(20, 3), nil), OptionKeyTimeout(10*time.Millisecond))
+	}()
+	_, err := inputWriter.Write([]byte("g"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case k := <-pressed:
+		if k != "g" {
+			t.Errorf("pressed %q, want g", k)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the pending key did not time out")
+	}
+	inputWriter.Close()
+	err = <-done
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := NewScreen(createView, 1, 1, OptionKeyTimeout(0)); err == nil {
+		t.Error("OptionKeyTimeout(0): expected an error")
+	}
+}
+
+func TestRun_incompletePaste(t *testing.T) {
+	inputReader, inputWriter := io.Pipe()
+	pasted := make(chan string, 1)
+	createView := func() *View {
+		return String("").OnPaste(func(text string) any {
+			pasted <- text
+			return true
+		})
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(createView, OptionIO(inputReader, io.Discard, fixedSize(20, 3), nil))
+	}()
+	defer inputWriter.Close()
+	for _, input := range []string{"\x1b[200~abc", "q\x03"} {
+		_, err := inputWriter.Write([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case text := <-pasted:
+		if text != "abcq" {
+			t.Errorf("pasted %q, want %q", text, "abcq")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the paste without its end was not read")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Ctrl+C after the paste did not end the program")
+	}
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
+type keyPending struct{}
+
+// KeyPending keeps the keys pending when it is returned from a handler of KeySequenceHandler,
+// because they begin a sequence of keys.
+var KeyPending = keyPending{}
+
+// PendingKeys returns the keys of an incomplete sequence in the program, e.g. to show them in a status line.
+// It must be called while the program is rendering its views, e.g. in createView of Run, and panics otherwise.
+func PendingKeys() []key.Event {
+	c := currentStates("PendingKeys")
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]key.Event(nil), c.pendingKeys...)
+}
+
+// dispatchSequence sends the key with the pending keys to the handlers of sequences in the order of bubbleTargets.
+// If none of them handles the keys, the pending keys are sent to the other handlers,
+// and the key is dispatched again as the first key of a sequence.
+// It reports whether the program should terminate.
+func dispatchSequence(cfg *config, e key.Event) bool {
+	keys := append(cfg.pendingKeys[:len(cfg.pendingKeys):len(cfg.pendingKeys)], e)
+	for _, v := range bubbleTargets(cfg) {
+		if v.sequenceHandler == nil {
+			continue
+		}
+		switch result := v.sequenceHandler(keys); result.(type) {
+		case nil:
+			continue
+		case keyPending:
+			cfg.pendingKeys = keys
+			return false
+		default:
+			cfg.pendingKeys = nil
+			return handleResult(cfg, result)
+		}
+	}
+	if expirePendingKeys(cfg) {
+		return true
+	}
+	return dispatchKey(cfg, e)
+}
+
+// expirePendingKeys gives the pending keys back to the handlers of single keys.
+// It reports whether the program should terminate.
+func expirePendingKeys(cfg *config) bool {
+	keys := cfg.pendingKeys
+	cfg.pendingKeys = nil
+	for _, k := range keys {
+		if handleKey(cfg, k, false) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *config) keyTimeoutOrDefault() time.Duration {
+	if cfg.keyTimeout == 0 {
+		return defaultKeyTimeout
+	}
+	return cfg.keyTimeout
+}
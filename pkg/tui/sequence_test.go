@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dytlzl/tervi/pkg/key"
+)
+
+func TestKeySequenceHandler(t *testing.T) {
+	pressed := []string{}
+	saved := 0
+	isCtrl := func(e key.Event, code rune) bool {
+		return e.Code == code && e.Mod == key.Ctrl
+	}
+	createView := func() *View {
+		pending := []string{}
+		for _, e := range PendingKeys() {
+			pending = append(pending, e.String())
+		}
+		return VStack(
+			String(strings.Join(pending, " ")).KeySequenceHandler(func(keys []key.Event) any {
+				switch {
+				case len(keys) == 1 && isCtrl(keys[0], 'x'):
+					return KeyPending
+				case len(keys) == 2 && isCtrl(keys[0], 'x') && isCtrl(keys[1], 's'):
+					saved++
+					return true
+				}
+				return nil
+			}),
+		).KeyEventHandler(func(e key.Event) any {
+			pressed = append(pressed, e.String())
+			return true
+		})
+	}
+	s, err := NewScreen(createView, 20, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		keys    []rune
+		text    string
+		pressed string
+		saved   int
+	}{
+		{[]rune{key.CtrlX}, "ctrl+x", "", 0},
+		{[]rune{key.CtrlS}, "", "", 1},
+		{[]rune{'a', key.CtrlX}, "ctrl+x", "a", 1},
+		// A key that does not complete the sequence gives back the pending keys.
+		{[]rune{'b'}, "", "a ctrl+x b", 1},
+		// A key that does not complete the sequence can begin another one.
+		{[]rune{key.CtrlX, key.CtrlX}, "ctrl+x", "a ctrl+x b ctrl+x", 1},
+		{[]rune{key.CtrlS}, "", "a ctrl+x b ctrl+x", 2},
+	}
+	for i, step := range steps {
+		err := s.SendKeys(step.keys...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Text(); got != step.text {
+			t.Errorf("step %d: text = %q, want %q", i, got, step.text)
+		}
+		if got := strings.Join(pressed, " "); got != step.pressed {
+			t.Errorf("step %d: pressed = %q, want %q", i, got, step.pressed)
+		}
+		if saved != step.saved {
+			t.Errorf("step %d: saved = %d, want %d", i, saved, step.saved)
+		}
+	}
+
+	err = s.SendKeys(key.CtrlX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.ExpirePendingKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(pressed, " "); got != "a ctrl+x b ctrl+x ctrl+x" {
+		t.Errorf("after timeout: pressed = %q", got)
+	}
+	if got := s.Text(); got != "" {
+		t.Errorf("after timeout: text = %q", got)
+	}
+}
+
+func TestKeySequenceHandler_focused(t *testing.T) {
+	text := ""
+	jumped := 0
+	s, err := NewScreen(func() *View {
+		return VStack(
+			String(text).Focusable("input").KeyHandler(func(r rune) any {
+				if r >= 'a' && r <= 'z' {
+					text += string(r)
+					return true
+				}
+				return nil
+			}),
+		).KeySequenceHandler(func(keys []key.Event) any {
+			switch {
+			case len(keys) == 1 && keys[0].Is("j"):
+				return true
+			case len(keys) == 1 && keys[0].Is("ctrl+x"):
+				return KeyPending
+			case len(keys) == 2 && keys[0].Is("ctrl+x") && keys[1].Is("j"):
+				jumped++
+				return true
+			}
+			return nil
+		})
+	}, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The focused view handles the keys before the handler of sequences of its ancestor.
+	err = s.SendKeys('a', 'j', 'o')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "ajo" {
+		t.Errorf("text = %q, want %q", text, "ajo")
+	}
+	// The keys passed on by the focused view begin sequences, which receive the next key first.
+	err = s.SendKeys(key.CtrlX, 'j')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "ajo" || jumped != 1 {
+		t.Errorf("ctrl+x j: text = %q, jumped = %d", text, jumped)
+	}
+}
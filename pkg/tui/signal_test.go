@@ -0,0 +1,126 @@
+//go:build linux
+
+package tui
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a pseudo terminal, and returns its master and slave.
+func openPTY(t *testing.T) (*os.File, *os.File) {
+	t.Helper()
+	// The master is non-blocking, so that closing it ends reading it.
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("no pseudo terminal: %v", err)
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	t.Cleanup(func() { master.Close() })
+	err = unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slave, err := os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { slave.Close() })
+	err = unix.IoctlSetWinsize(int(slave.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Row: 3, Col: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return master, slave
+}
+
+func TestRun_terminated(t *testing.T) {
+	master, slave := openPTY(t)
+	stdin, stderr := os.Stdin, os.Stderr
+	os.Stdin, os.Stderr = slave, slave
+	defer func() {
+		os.Stdin, os.Stderr = stdin, stderr
+	}()
+	before, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := make(chan []byte)
+	go func() {
+		defer close(output)
+		b := make([]byte, 4096)
+		for {
+			n, err := master.Read(b)
+			if err != nil {
+				return
+			}
+			output <- append([]byte(nil), b[:n]...)
+		}
+	}()
+
+	// The goroutine of os/signal starts at the first Notify and never ends.
+	started := make(chan os.Signal, 1)
+	signal.Notify(started, syscall.SIGWINCH)
+	signal.Stop(started)
+	goroutines := runtime.NumGoroutine()
+
+	var once sync.Once
+	err = Run(func() *View {
+		// The key is typed after the views are rendered for the first time,
+		// so that the signal comes while the standard input is being read.
+		once.Do(func() {
+			go func() { _, _ = master.Write([]byte("a")) }()
+		})
+		return String("running").KeyHandler(func(r rune) any {
+			_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+			return nil
+		})
+	})
+	if err == nil || !strings.Contains(err.Error(), "terminated") {
+		t.Errorf("Run() error = %v, want the signal", err)
+	}
+	after, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *after != *before {
+		t.Error("the mode of the terminal is not restored")
+	}
+	var out []byte
+	timeout := time.After(time.Second)
+	for !bytes.HasSuffix(out, []byte("\x1b[?1049l\x1b[u")) {
+		select {
+		case b, ok := <-output:
+			if !ok {
+				t.Fatalf("the screen is not restored: %q", out)
+			}
+			out = append(out, b...)
+		case <-timeout:
+			t.Fatalf("the screen is not restored: %q", out)
+		}
+	}
+
+	// Nothing is left reading the standard input after Run returns.
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines are left after Run returns", runtime.NumGoroutine()-goroutines)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	slave.Close()
+	for range output {
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// canSuspend reports whether the program can be suspended by Ctrl+Z.
+const canSuspend = true
+
+// terminationSignals end the program after restoring the terminal.
+var terminationSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// notifyContinue returns a channel that receives a value when the process is continued by SIGCONT.
+func notifyContinue() (<-chan os.Signal, func()) {
+	continued := make(chan os.Signal, 1)
+	signal.Notify(continued, syscall.SIGCONT)
+	return continued, func() {
+		signal.Stop(continued)
+	}
+}
+
+// stopProcess stops the process group like Ctrl+Z on a shell.
+func stopProcess() error {
+	return syscall.Kill(0, syscall.SIGTSTP)
+}
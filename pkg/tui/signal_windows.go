@@ -0,0 +1,24 @@
+//go:build windows
+
+package tui
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// canSuspend reports whether the program can be suspended by Ctrl+Z.
+const canSuspend = false
+
+// terminationSignals end the program after restoring the terminal.
+var terminationSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// notifyContinue returns nil, because Windows has no job control.
+func notifyContinue() (<-chan os.Signal, func()) {
+	return nil, func() {}
+}
+
+func stopProcess() error {
+	return errors.New("job control is not supported on Windows")
+}
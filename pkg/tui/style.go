@@ -1,40 +1,71 @@
 package tui
 
+import (
+	"strings"
+
+	"github.com/dytlzl/tervi/pkg/color"
+)
+
 type style struct {
-	f256          uint8
-	b256          uint8
-	bold          bool
-	italic        bool
-	strikethrough bool
-	underline     bool
-	reverse       bool
-	hasCursor     bool
+	fg        color.Color
+	bg        color.Color
+	attr      attribute
+	hasCursor bool
 }
 
+// attribute is a set of the text attributes of SGR.
+type attribute uint8
+
+const (
+	attrBold attribute = 1 << iota
+	attrItalic
+	attrUnderline
+	attrReverse
+	attrStrikethrough
+)
+
 func (s *style) merge(defaultStyle style) {
 	if s == nil {
 		s = &defaultStyle
 	} else {
-		if s.f256 == 0 {
-			s.f256 = defaultStyle.f256
-		}
-		if s.b256 == 0 {
-			s.b256 = defaultStyle.b256
+		if s.fg.IsDefault() {
+			s.fg = defaultStyle.fg
 		}
-		if !s.reverse {
-			s.reverse = defaultStyle.reverse
-		}
-		if !s.bold {
-			s.bold = defaultStyle.bold
-		}
-		if !s.italic {
-			s.italic = defaultStyle.italic
-		}
-		if !s.strikethrough {
-			s.strikethrough = defaultStyle.strikethrough
-		}
-		if !s.underline {
-			s.underline = defaultStyle.underline
+		if s.bg.IsDefault() {
+			s.bg = defaultStyle.bg
 		}
+		s.attr |= defaultStyle.attr
+	}
+}
+
+func (s style) String() string {
+	attributes := make([]string, 0, 8)
+	if !s.fg.IsDefault() {
+		attributes = append(attributes, "fg="+s.fg.String())
+	}
+	if !s.bg.IsDefault() {
+		attributes = append(attributes, "bg="+s.bg.String())
+	}
+	if s.attr&attrBold != 0 {
+		attributes = append(attributes, "bold")
+	}
+	if s.attr&attrItalic != 0 {
+		attributes = append(attributes, "italic")
+	}
+	if s.attr&attrUnderline != 0 {
+		attributes = append(attributes, "underline")
+	}
+	if s.attr&attrReverse != 0 {
+		attributes = append(attributes, "reverse")
+	}
+	if s.attr&attrStrikethrough != 0 {
+		attributes = append(attributes, "strikethrough")
+	}
+	if s.hasCursor {
+		attributes = append(attributes, "cursor")
+	}
+	if len(attributes) == 0 {
+		return "default"
 	}
+	return strings.Join(attributes, " ")
 }
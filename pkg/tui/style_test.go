@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 	"unsafe"
+
+	"github.com/dytlzl/tervi/pkg/color"
 )
 
 func Test_sizeOfStyle(t *testing.T) {
@@ -15,3 +17,25 @@ func Test_sizeOfStyle(t *testing.T) {
 		}
 	})
 }
+
+func Test_appendColor(t *testing.T) {
+	tests := []struct {
+		name         string
+		color        color.Color
+		isBackground bool
+		want         string
+	}{
+		{name: "default foreground", color: color.Default, want: "39"},
+		{name: "ANSI foreground", color: color.Red, want: "31"},
+		{name: "bright ANSI background", color: color.BrightBlue, isBackground: true, want: "104"},
+		{name: "palette color 0", color: color.Palette(0), want: "38;5;0"},
+		{name: "RGB background", color: color.RGB(29, 161, 242), isBackground: true, want: "48;2;29;161;242"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(appendColor(nil, tt.color, tt.isBackground)); got != tt.want {
+				t.Errorf("appendColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"fmt"
 	"os"
 
 	"golang.org/x/term"
@@ -11,42 +10,49 @@ func TermSize() (width int, height int, err error) {
 	return term.GetSize(int(os.Stdin.Fd()))
 }
 
-var buffer string
+func (w *generalCellWriter) push(s string) {
+	w.out = append(w.out, s...)
+}
 
-func flush() {
-	_, _ = fmt.Fprint(os.Stderr, buffer)
-	buffer = ""
+func (w *generalCellWriter) csi(s string) {
+	w.out = append(w.out, "\x1b["...)
+	w.out = append(w.out, s...)
 }
 
-func push(s string) {
-	buffer += s
+func (w *generalCellWriter) smcup() {
+	w.csi("?1049h")
 }
 
-func csi(s string) {
-	buffer += "\x1b[" + s
+func (w *generalCellWriter) rmcup() {
+	w.csi("?1049l")
 }
 
-func smcup() {
-	csi("?1049h")
+func (w *generalCellWriter) showCursor() {
+	w.csi("?25h")
 }
 
-func rmcup() {
-	csi("?1049l")
+func (w *generalCellWriter) hideCursor() {
+	w.csi("?25l")
 }
 
-func showCursor() {
-	csi("?25h")
+// enableMouse enables the reporting of button presses and drags in the SGR encoding.
+func (w *generalCellWriter) enableMouse() {
+	w.csi("?1000h")
+	w.csi("?1002h")
+	w.csi("?1006h")
 }
 
-func hideCursor() {
-	csi("?25l")
+func (w *generalCellWriter) disableMouse() {
+	w.csi("?1006l")
+	w.csi("?1002l")
+	w.csi("?1000l")
 }
 
-func clearAll() {
-	csi("2J")
+// enableBracketedPaste makes the terminal enclose pasted text with ESC [ 200 ~ and ESC [ 201 ~.
+func (w *generalCellWriter) enableBracketedPaste() {
+	w.csi("?2004h")
 }
 
-func origin() {
-	csi("1000A")
-	push("\r")
+func (w *generalCellWriter) disableBracketedPaste() {
+	w.csi("?2004l")
 }
@@ -3,28 +3,53 @@ package tui
 import (
 	"fmt"
 
+	"github.com/dytlzl/tervi/pkg/color"
 	"github.com/dytlzl/tervi/pkg/key"
 )
 
 type View struct {
-	absoluteWidth   int
-	absoluteHeight  int
-	relativeWidth   uint8
-	relativeHeight  uint8
-	paddingTop      uint8
-	paddingLeading  uint8
-	paddingBottom   uint8
-	paddingTrailing uint8
-	priority        int8
-	allowOverflow   bool
-	offsetY         int
-	title           string
-	dir             direction
-	style           *style
-	border          *style
-	children        func() []*View
-	keyHandler      func(rune) any
-	content         func() []text
+	absoluteWidth     int
+	absoluteHeight    int
+	relativeWidth     uint8
+	relativeHeight    uint8
+	isFlex            bool
+	grow              int
+	shrink            int
+	basis             int
+	minWidth          int
+	minHeight         int
+	maxWidth          int
+	maxHeight         int
+	alignX            Alignment
+	alignY            Alignment
+	spacing           int
+	paddingTop        uint8
+	paddingLeading    uint8
+	paddingBottom     uint8
+	paddingTrailing   uint8
+	priority          int8
+	allowOverflow     bool
+	offsetY           int
+	title             string
+	dir               direction
+	style             *style
+	border            *style
+	children          func() []*View
+	keyHandler        func(key.Event) any
+	keyCaptureHandler func(key.Event) any
+	sequenceHandler   func([]key.Event) any
+	clickHandler      func(MouseEvent) any
+	scrollHandler     func(MouseEvent) any
+	mouseHandler      func(MouseEvent) any
+	pasteHandler      func(string) any
+	focusID           string
+	focusedModifier   func(*View) *View
+	content           func() []text
+	frame             rect  // frame laid out by moldView
+	clip              rect  // visible area of the frame
+	parent            *View // parent laid out by moldView
+	// containsFocusable is true if a descendant is focusable, set by moldView.
+	containsFocusable bool
 }
 
 type direction int
@@ -57,6 +82,95 @@ func (v *View) AbsoluteSize(width, height int) *View {
 	return v
 }
 
+// Flex makes the size of the view flexible along the direction of the HStack or VStack that contains it.
+// The space left over by the basis sizes of the children is distributed to them in proportion to grow,
+// and the lacking space is taken from them in proportion to shrink times the basis.
+// For example, Flex(2, 1) and Flex(1, 1) split the space left by the other children at a 2:1 ratio.
+// Without Flex, a view of no size grows like Flex(1, 0), and the others keep their sizes.
+func (v *View) Flex(grow, shrink int) *View {
+	if v == nil {
+		return nil
+	}
+	v.isFlex = true
+	v.grow = If(grow > 0, grow, 0)
+	v.shrink = If(shrink > 0, shrink, 0)
+	return v
+}
+
+// Basis specifies the size of the view along the direction of the stack before it grows or shrinks.
+// 0 means the size specified by AbsoluteSize or RelativeSize, or the natural height of a text in a VStack.
+// Without Flex, the view keeps the basis size like a view of AbsoluteSize.
+func (v *View) Basis(size int) *View {
+	if v == nil {
+		return nil
+	}
+	v.basis = size
+	return v
+}
+
+// MinSize specifies the minimum width and height of the view, which bound it when it shrinks.
+// 0 means no minimum.
+func (v *View) MinSize(width, height int) *View {
+	if v == nil {
+		return nil
+	}
+	v.minWidth = width
+	v.minHeight = height
+	return v
+}
+
+// MaxSize specifies the maximum width and height of the view, which bound it when it grows
+// or fills its parent. 0 means no maximum.
+func (v *View) MaxSize(width, height int) *View {
+	if v == nil {
+		return nil
+	}
+	v.maxWidth = width
+	v.maxHeight = height
+	return v
+}
+
+// Alignment is the position of the children of a stack in the space across the direction of the stack.
+type Alignment uint8
+
+const (
+	// AlignCenter centers the children. It is the default.
+	AlignCenter Alignment = iota
+	// AlignLeading puts the children on the left or at the top.
+	AlignLeading
+	// AlignTrailing puts the children on the right or at the bottom.
+	AlignTrailing
+	// AlignStretch stretches the children to fill the space, ignoring their sizes except MaxSize.
+	AlignStretch
+)
+
+// Alignment sets the alignment of the children of the stack.
+// When one value is specified, it applies to both the horizontal and the vertical alignment.
+// When two values are specified, the first applies to the horizontal alignment, the second to the vertical.
+// HStack aligns its children vertically, VStack horizontally, and ZStack in both directions.
+// Children of no size fill the space regardless of the alignment.
+func (v *View) Alignment(alignments ...Alignment) *View {
+	if v == nil {
+		return nil
+	}
+	switch len(alignments) {
+	case 1:
+		v.alignX, v.alignY = alignments[0], alignments[0]
+	case 2:
+		v.alignX, v.alignY = alignments[0], alignments[1]
+	}
+	return v
+}
+
+// Spacing sets the gap between the children of HStack or VStack.
+func (v *View) Spacing(n int) *View {
+	if v == nil {
+		return nil
+	}
+	v.spacing = If(n > 0, n, 0)
+	return v
+}
+
 // Padding sets padding values to the view.
 // When one value is specified, it applies the same padding to all four sides.
 // When two values are specified, the first padding applies to the top and bottom, the second to the left and right.
@@ -95,26 +209,26 @@ func (v *View) Title(title string) *View {
 }
 
 // FGColor sets a foreground color to the view.
-func (v *View) FGColor(color uint8) *View {
+func (v *View) FGColor(c color.Color) *View {
 	if v == nil {
 		return nil
 	}
 	if v.style == nil {
 		v.style = new(style)
 	}
-	v.style.f256 = color
+	v.style.fg = c
 	return v
 }
 
 // BGColor sets a background color to the view.
-func (v *View) BGColor(color uint8) *View {
+func (v *View) BGColor(c color.Color) *View {
 	if v == nil {
 		return nil
 	}
 	if v.style == nil {
 		v.style = new(style)
 	}
-	v.style.b256 = color
+	v.style.bg = c
 	return v
 }
 
@@ -126,7 +240,7 @@ func (v *View) Bold() *View {
 	if v.style == nil {
 		v.style = new(style)
 	}
-	v.style.bold = true
+	v.style.attr |= attrBold
 	return v
 }
 
@@ -138,7 +252,7 @@ func (v *View) Italic() *View {
 	if v.style == nil {
 		v.style = new(style)
 	}
-	v.style.italic = true
+	v.style.attr |= attrItalic
 	return v
 }
 
@@ -150,7 +264,7 @@ func (v *View) Underline() *View {
 	if v.style == nil {
 		v.style = new(style)
 	}
-	v.style.underline = true
+	v.style.attr |= attrUnderline
 	return v
 }
 
@@ -162,7 +276,7 @@ func (v *View) Strikethrough() *View {
 	if v.style == nil {
 		v.style = new(style)
 	}
-	v.style.strikethrough = true
+	v.style.attr |= attrStrikethrough
 	return v
 }
 
@@ -174,7 +288,7 @@ func (v *View) Reverse() *View {
 	if v.style == nil {
 		v.style = new(style)
 	}
-	v.style.reverse = true
+	v.style.attr |= attrReverse
 	return v
 }
 
@@ -216,7 +330,26 @@ func (v *View) AllowOverflow() *View {
 	return v
 }
 
+// KeyHandler sets a handler called with the keys in the encoding of the key package,
+// e.g. key.CtrlA or key.ArrowUp|key.ModShift. Releases of keys are not passed to it.
+// The handlers of inner views are called earlier among the views of the same priority.
+// Returning nil passes the key to the next view, e.g. the parent.
 func (v *View) KeyHandler(fn func(rune) any) *View {
+	if v == nil {
+		return nil
+	}
+	v.keyHandler = func(e key.Event) any {
+		if e.Action == key.Release {
+			return nil
+		}
+		return fn(e.Legacy())
+	}
+	return v
+}
+
+// KeyEventHandler sets a handler called with the key events in the same order as KeyHandler.
+// Returning nil passes the event to the next view, e.g. the parent.
+func (v *View) KeyEventHandler(fn func(key.Event) any) *View {
 	if v == nil {
 		return nil
 	}
@@ -224,6 +357,92 @@ func (v *View) KeyHandler(fn func(rune) any) *View {
 	return v
 }
 
+// KeyCaptureHandler sets a handler called with the keys before the handlers of the descendants,
+// e.g. to intercept keys before the focused view. Releases of keys are not passed to it.
+// Returning nil passes the key to the views inside it.
+func (v *View) KeyCaptureHandler(fn func(rune) any) *View {
+	if v == nil {
+		return nil
+	}
+	v.keyCaptureHandler = func(e key.Event) any {
+		if e.Action == key.Release {
+			return nil
+		}
+		return fn(e.Legacy())
+	}
+	return v
+}
+
+// KeyEventCaptureHandler sets a handler called with the key events before the handlers of the descendants.
+// Returning nil passes the event to the views inside it.
+func (v *View) KeyEventCaptureHandler(fn func(key.Event) any) *View {
+	if v == nil {
+		return nil
+	}
+	v.keyCaptureHandler = fn
+	return v
+}
+
+// KeySequenceHandler sets a handler of sequences of keys, such as g g or Ctrl+X Ctrl+S.
+// It is called with the keys pressed since the last sequence ended, and returns KeyPending
+// if they begin a sequence that it handles, which keeps them pending until the next key.
+// It receives a key in the bubble phase before the key handler of the view,
+// so the inner views and the view with the focus can handle the key before it begins a sequence.
+// While keys are pending, the next key is sent to the handlers of sequences before the other handlers.
+// If none of them handles the pending keys and the next key, or the keys time out,
+// the pending keys are given back to the other handlers. See OptionKeyTimeout.
+// Releases of keys are not passed to it.
+func (v *View) KeySequenceHandler(fn func(keys []key.Event) any) *View {
+	if v == nil {
+		return nil
+	}
+	v.sequenceHandler = fn
+	return v
+}
+
+// OnClick sets a handler called when the left button is pressed on the view.
+// The position of the event is relative to the frame of the view.
+// Returning nil passes the event to the views below.
+func (v *View) OnClick(fn func(MouseEvent) any) *View {
+	if v == nil {
+		return nil
+	}
+	v.clickHandler = fn
+	return v
+}
+
+// OnScroll sets a handler called when the mouse wheel is rotated on the view.
+func (v *View) OnScroll(fn func(MouseEvent) any) *View {
+	if v == nil {
+		return nil
+	}
+	v.scrollHandler = fn
+	return v
+}
+
+// OnMouse sets a handler called with every mouse event on the view, before OnClick and OnScroll.
+func (v *View) OnMouse(fn func(MouseEvent) any) *View {
+	if v == nil {
+		return nil
+	}
+	v.mouseHandler = fn
+	return v
+}
+
+// OnPaste sets a handler called with the text pasted into the terminal.
+// Views receive pastes in the same order as the handlers of KeyHandler.
+// Returning nil passes the text to the next view.
+func (v *View) OnPaste(fn func(string) any) *View {
+	if v == nil {
+		return nil
+	}
+	v.pasteHandler = fn
+	return v
+}
+
+// Priority sets the priority of the view to receive keys and pastes.
+// The view and the views inside it receive them earlier than the views of lower priority,
+// e.g. a dialog shown over the other views.
 func (v *View) Priority(priority int8) *View {
 	if v == nil {
 		return nil
@@ -240,15 +459,15 @@ func (v *View) Hidden(isHidden bool) *View {
 	}
 }
 
-func BorderOptionFGColor(color uint8) func(*View) {
+func BorderOptionFGColor(c color.Color) func(*View) {
 	return func(v *View) {
-		v.border.f256 = color
+		v.border.fg = c
 	}
 }
 
-func BorderOptionBGColor(color uint8) func(*View) {
+func BorderOptionBGColor(c color.Color) func(*View) {
 	return func(v *View) {
-		v.border.b256 = color
+		v.border.bg = c
 	}
 }
 
@@ -289,6 +508,8 @@ func List(selected *int, views ...*View) *View {
 		height := v.absoluteHeight - int(v.paddingTop) - int(v.paddingBottom)
 		for i := range views {
 			views[i].absoluteHeight = 1
+			// The items not laid out this time do not receive clicks.
+			views[i].clip = rect{}
 			if i == *selected {
 				views[i].Underline()
 			}
@@ -313,6 +534,27 @@ func List(selected *int, views ...*View) *View {
 		}
 		return true
 	})
+	v.OnClick(func(e MouseEvent) any {
+		x, y := e.X+v.frame.x, e.Y+v.frame.y
+		for i, item := range views {
+			if item.clip.contains(x, y) {
+				*selected = i
+				return true
+			}
+		}
+		return nil
+	})
+	v.OnScroll(func(e MouseEvent) any {
+		switch e.Button {
+		case MouseWheelUp:
+			*selected--
+		case MouseWheelDown:
+			*selected++
+		default:
+			return nil
+		}
+		return true
+	})
 	return v
 }
 
@@ -347,9 +589,23 @@ func ScrollView(views ...*View) *View {
 		}
 		return true
 	})
+	v.OnScroll(func(e MouseEvent) any {
+		switch e.Button {
+		case MouseWheelUp:
+			*offset += scrollStep
+		case MouseWheelDown:
+			*offset -= scrollStep
+		default:
+			return nil
+		}
+		return true
+	})
 	return v
 }
 
+// scrollStep is the number of lines that ScrollView scrolls per notch of the mouse wheel.
+const scrollStep = 3
+
 func String(s string) *View {
 	view := &View{}
 	view.content = func() []text {
@@ -0,0 +1,232 @@
+// Package tuissh serves tervi programs over SSH.
+//
+// Each session with a pseudo terminal runs its own program, which has its own size, input and states of hooks.
+// The host keys and the authentication are configured by ssh.ServerConfig of golang.org/x/crypto/ssh.
+package tuissh
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/dytlzl/tervi/pkg/tui"
+	"golang.org/x/crypto/ssh"
+)
+
+// Handler runs a program on the session, typically by calling Session.Run.
+// The session is closed when it returns.
+type Handler func(s *Session) error
+
+// RunView returns a Handler that runs the view returned by createView on each session.
+func RunView(createView func() *tui.View, options ...tui.Option) Handler {
+	return func(s *Session) error {
+		return s.Run(createView, options...)
+	}
+}
+
+// Serve accepts connections on the listener and handles the sessions on them.
+// It returns when the listener fails, e.g. when it is closed.
+func Serve(l net.Listener, config *ssh.ServerConfig, handler Handler) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			_ = ServeConn(conn, config, handler)
+		}()
+	}
+}
+
+// ServeConn runs the SSH handshake on the connection and handles its sessions until it is closed.
+func ServeConn(conn net.Conn, config *ssh.ServerConfig, handler Handler) error {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to handshake: %w", err)
+	}
+	defer serverConn.Close()
+	go ssh.DiscardRequests(requests)
+	HandleChannels(channels, handler)
+	return nil
+}
+
+// HandleChannels runs handler on each session channel in its own goroutine,
+// and rejects the other types of channels. It returns when channels is closed.
+func HandleChannels(channels <-chan ssh.NewChannel, handler Handler) {
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		go handleSession(newChannel, handler)
+	}
+}
+
+// Payloads of the requests of RFC 4254
+type ptyRequest struct {
+	Term    string
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+	Modes   string
+}
+
+type windowChangeRequest struct {
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+}
+
+type envRequest struct {
+	Name  string
+	Value string
+}
+
+type exitStatus struct {
+	Status uint32
+}
+
+func handleSession(newChannel ssh.NewChannel, handler Handler) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	s := &Session{
+		channel: channel,
+		env:     map[string]string{},
+		resized: make(chan struct{}, 1),
+	}
+	isStarted := false
+	for req := range requests {
+		ok := false
+		switch req.Type {
+		case "pty-req":
+			var p ptyRequest
+			if ssh.Unmarshal(req.Payload, &p) == nil {
+				s.setPty(p.Term, int(p.Columns), int(p.Rows))
+				ok = true
+			}
+		case "window-change":
+			var w windowChangeRequest
+			if ssh.Unmarshal(req.Payload, &w) == nil {
+				s.resize(int(w.Columns), int(w.Rows))
+				ok = true
+			}
+		case "env":
+			var e envRequest
+			if ssh.Unmarshal(req.Payload, &e) == nil && acceptedEnv[e.Name] {
+				s.setenv(e.Name, e.Value)
+				ok = true
+			}
+		case "shell":
+			ok = !isStarted
+		}
+		if req.WantReply {
+			_ = req.Reply(ok, nil)
+		}
+		if req.Type == "shell" && ok {
+			isStarted = true
+			go s.serve(handler)
+		}
+	}
+}
+
+// acceptedEnv are the environment variables accepted from clients, which decide the colors.
+// The others are refused, e.g. HOME and TERMINFO, which would make the server read files chosen by the client.
+var acceptedEnv = map[string]bool{
+	"TERM":      true,
+	"COLORTERM": true,
+	"NO_COLOR":  true,
+}
+
+// Session is an SSH session that runs a program.
+type Session struct {
+	channel ssh.Channel
+	mutex   sync.Mutex
+	hasPty  bool
+	env     map[string]string
+	width   int
+	height  int
+	resized chan struct{}
+}
+
+func (s *Session) setPty(term string, width, height int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.hasPty = true
+	s.env["TERM"] = term
+	s.width, s.height = width, height
+}
+
+func (s *Session) resize(width, height int) {
+	s.mutex.Lock()
+	s.width, s.height = width, height
+	s.mutex.Unlock()
+	select {
+	case s.resized <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Session) setenv(name, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.env[name] = value
+}
+
+// Getenv returns the environment variable sent by the client.
+// TERM is the terminal type of the pseudo terminal.
+// Only TERM, COLORTERM and NO_COLOR are accepted from the client.
+func (s *Session) Getenv(name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.env[name]
+}
+
+// Size returns the size of the pseudo terminal.
+func (s *Session) Size() (width, height int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.width, s.height, nil
+}
+
+// Channel returns the channel of the session.
+func (s *Session) Channel() ssh.Channel {
+	return s.channel
+}
+
+// Run runs the view returned by createView on the pseudo terminal of the session like tui.Run.
+// The colors are decided from the environment variables sent by the client,
+// unless tui.OptionColorProfile is given.
+func (s *Session) Run(createView func() *tui.View, options ...tui.Option) error {
+	options = append([]tui.Option{
+		tui.OptionIO(s.channel, s.channel, s.Size, s.resized),
+		tui.OptionColorProfile(tui.DetectColorProfile(s.Getenv)),
+	}, options...)
+	return tui.Run(createView, options...)
+}
+
+func (s *Session) serve(handler Handler) {
+	defer s.channel.Close()
+	s.mutex.Lock()
+	hasPty := s.hasPty
+	s.mutex.Unlock()
+	var err error
+	if hasPty {
+		err = handler(s)
+	} else {
+		err = fmt.Errorf("a pseudo terminal is required")
+	}
+	status := exitStatus{}
+	if err != nil {
+		// The terminal of the client is still in raw mode.
+		message := strings.ReplaceAll(err.Error(), "\n", "\r\n")
+		_, _ = fmt.Fprintf(s.channel.Stderr(), "%s\r\n", message)
+		status.Status = 1
+	}
+	_, _ = s.channel.SendRequest("exit-status", false, ssh.Marshal(&status))
+}
@@ -0,0 +1,229 @@
+package tuissh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dytlzl/tervi/pkg/tui"
+	"golang.org/x/crypto/ssh"
+)
+
+func startServer(t *testing.T, handler Handler) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		_ = Serve(l, config, handler)
+	}()
+	return l.Addr().String()
+}
+
+func dial(t *testing.T, addr string) *ssh.Client {
+	t.Helper()
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// output collects the output of a session.
+type output struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.buffer.Write(p)
+}
+
+func (o *output) waitFor(t *testing.T, s string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		o.mutex.Lock()
+		ok := strings.Contains(o.buffer.String(), s)
+		o.mutex.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	t.Fatalf("output does not contain %q: %q", s, o.buffer.String())
+}
+
+type terminal struct {
+	session *ssh.Session
+	stdin   io.WriteCloser
+	out     *output
+}
+
+func openTerminal(t *testing.T, client *ssh.Client, width, height int) *terminal {
+	t.Helper()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(output)
+	session.Stdout = out
+	session.Stderr = out
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = session.Setenv("COLORTERM", "truecolor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = session.RequestPty("xterm", height, width, ssh.TerminalModes{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = session.Shell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &terminal{session: session, stdin: stdin, out: out}
+}
+
+func TestRunView(t *testing.T) {
+	addr := startServer(t, RunView(func() *tui.View {
+		last, setLast := tui.UseState("none")
+		return tui.String("last=" + last).KeyHandler(func(r rune) any {
+			if r == 'q' {
+				return tui.Terminate
+			}
+			setLast(strings.Repeat(string(r), 4))
+			return true
+		})
+	}))
+	client := dial(t, addr)
+
+	t1 := openTerminal(t, client, 20, 5)
+	t2 := openTerminal(t, client, 30, 8)
+	t1.out.waitFor(t, "last=none")
+	t2.out.waitFor(t, "last=none")
+
+	// Each session has its own state.
+	_, _ = t1.stdin.Write([]byte("a"))
+	t1.out.waitFor(t, "aaaa")
+	_, _ = t2.stdin.Write([]byte("b"))
+	t2.out.waitFor(t, "bbbb")
+
+	// The screen is drawn again at the new size.
+	err := t1.session.WindowChange(6, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1.out.waitFor(t, "\x1b[H\x1b[2Jlast=aaaa")
+
+	for _, term := range []*terminal{t1, t2} {
+		_, _ = term.stdin.Write([]byte("q"))
+		err = term.session.Wait()
+		if err != nil {
+			t.Errorf("Wait() error = %v", err)
+		}
+	}
+}
+
+func TestSession_withoutPty(t *testing.T) {
+	addr := startServer(t, RunView(func() *tui.View {
+		return tui.String("hello")
+	}))
+	client := dial(t, addr)
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := new(output)
+	session.Stderr = stderr
+	err = session.Shell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = session.Wait()
+	var exitErr *ssh.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitStatus() != 1 {
+		t.Errorf("Wait() error = %v, want exit status 1", err)
+	}
+	stderr.waitFor(t, "a pseudo terminal is required")
+}
+
+func TestSession_Getenv(t *testing.T) {
+	envs := make(chan [2]string, 1)
+	addr := startServer(t, func(s *Session) error {
+		envs <- [2]string{s.Getenv("TERM"), s.Getenv("COLORTERM")}
+		return nil
+	})
+	term := openTerminal(t, dial(t, addr), 20, 5)
+	err := term.session.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if got := <-envs; got != [2]string{"xterm", "truecolor"} {
+		t.Errorf("TERM and COLORTERM = %q", got)
+	}
+}
+
+func TestSession_Getenv_refused(t *testing.T) {
+	envs := make(chan [2]string, 1)
+	addr := startServer(t, func(s *Session) error {
+		// The terminfo database is not read out of its directories.
+		_ = tui.DetectColorProfile(s.Getenv)
+		envs <- [2]string{s.Getenv("HOME"), s.Getenv("TERMINFO")}
+		return nil
+	})
+	session, err := dial(t, addr).NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"HOME", "TERMINFO"} {
+		if err := session.Setenv(name, "/dev"); err == nil {
+			t.Errorf("Setenv(%s): expected an error", name)
+		}
+	}
+	err = session.RequestPty("../../dev/zero", 5, 20, ssh.TerminalModes{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = session.Shell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = session.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if got := <-envs; got != [2]string{} {
+		t.Errorf("HOME and TERMINFO = %q", got)
+	}
+}
@@ -0,0 +1,198 @@
+// Package tuitest provides helpers for snapshot and interaction tests of views.
+//
+// Snapshots are compared with golden files in the testdata directory,
+// and the golden files are rewritten when the tests run with the -update flag.
+package tuitest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dytlzl/tervi/pkg/key"
+	"github.com/dytlzl/tervi/pkg/tui"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// Tester drives a view laid out on an in-memory screen.
+// Any error fails the test immediately.
+type Tester struct {
+	t      testing.TB
+	screen *tui.Screen
+}
+
+// New lays out the view returned by createView on a screen of the given size.
+func New(t testing.TB, createView func() *tui.View, width, height int, options ...tui.Option) *Tester {
+	t.Helper()
+	screen, err := tui.NewScreen(createView, width, height, options...)
+	if err != nil {
+		t.Fatalf("failed to create screen: %v", err)
+	}
+	return &Tester{t: t, screen: screen}
+}
+
+// Screen returns the underlying screen.
+func (tt *Tester) Screen() *tui.Screen {
+	return tt.screen
+}
+
+// Press dispatches the keys in order, e.g. key.ArrowDown or 'a'.
+func (tt *Tester) Press(keys ...rune) *Tester {
+	tt.t.Helper()
+	err := tt.screen.SendKeys(keys...)
+	if err != nil {
+		tt.t.Fatalf("failed to press keys: %v", err)
+	}
+	return tt
+}
+
+// PressKeys dispatches the keys described as in key.Parse in order, e.g. "ctrl+p" or "shift+tab".
+func (tt *Tester) PressKeys(keys ...string) *Tester {
+	tt.t.Helper()
+	events := make([]key.Event, 0, len(keys))
+	for _, k := range keys {
+		e, err := key.Parse(k)
+		if err != nil {
+			tt.t.Fatalf("failed to parse key: %v", err)
+		}
+		events = append(events, e)
+	}
+	err := tt.screen.SendKeyEvents(events...)
+	if err != nil {
+		tt.t.Fatalf("failed to press keys: %v", err)
+	}
+	return tt
+}
+
+// Type decodes the input as the bytes read from a terminal, and dispatches the decoded keys.
+func (tt *Tester) Type(input string) *Tester {
+	tt.t.Helper()
+	err := tt.screen.SendInput(input)
+	if err != nil {
+		tt.t.Fatalf("failed to type %q: %v", input, err)
+	}
+	return tt
+}
+
+// Paste dispatches the text as pasted into the terminal.
+func (tt *Tester) Paste(text string) *Tester {
+	tt.t.Helper()
+	err := tt.screen.SendPaste(text)
+	if err != nil {
+		tt.t.Fatalf("failed to paste %q: %v", text, err)
+	}
+	return tt
+}
+
+// Click presses and releases the left button at the position on the screen.
+func (tt *Tester) Click(x, y int) *Tester {
+	tt.t.Helper()
+	return tt.Mouse(tui.MouseEvent{X: x, Y: y, Button: tui.MouseLeft, Action: tui.MousePress}).
+		Mouse(tui.MouseEvent{X: x, Y: y, Button: tui.MouseLeft, Action: tui.MouseRelease})
+}
+
+// Scroll rotates the mouse wheel at the position on the screen,
+// upward when delta is negative and downward when it is positive, once per unit.
+func (tt *Tester) Scroll(x, y, delta int) *Tester {
+	tt.t.Helper()
+	button := tui.MouseWheelDown
+	if delta < 0 {
+		button = tui.MouseWheelUp
+		delta = -delta
+	}
+	for i := 0; i < delta; i++ {
+		tt.Mouse(tui.MouseEvent{X: x, Y: y, Button: button, Action: tui.MousePress})
+	}
+	return tt
+}
+
+// Mouse dispatches the mouse event, whose position is relative to the screen.
+func (tt *Tester) Mouse(e tui.MouseEvent) *Tester {
+	tt.t.Helper()
+	err := tt.screen.SendMouse(e)
+	if err != nil {
+		tt.t.Fatalf("failed to send mouse event: %v", err)
+	}
+	return tt
+}
+
+// Send passes the event to the event handler.
+func (tt *Tester) Send(event any) *Tester {
+	tt.t.Helper()
+	err := tt.screen.Send(event)
+	if err != nil {
+		tt.t.Fatalf("failed to send event: %v", err)
+	}
+	return tt
+}
+
+// Resize changes the size of the screen.
+func (tt *Tester) Resize(width, height int) *Tester {
+	tt.t.Helper()
+	err := tt.screen.Resize(width, height)
+	if err != nil {
+		tt.t.Fatalf("failed to resize screen: %v", err)
+	}
+	return tt
+}
+
+// IsTerminated reports whether a handler has returned tui.Terminate.
+func (tt *Tester) IsTerminated() bool {
+	return tt.screen.IsTerminated()
+}
+
+// Text returns the screen as plain text.
+func (tt *Tester) Text() string {
+	return tt.screen.Text()
+}
+
+// Styles returns the style grid of the screen.
+func (tt *Tester) Styles() string {
+	return tt.screen.StyleGrid()
+}
+
+// Snapshot returns the plain text followed by the style grid.
+func (tt *Tester) Snapshot() string {
+	return tt.screen.Text() + "\n\n" + tt.screen.StyleGrid()
+}
+
+// AssertText fails the test if the plain text of the screen differs from want.
+func (tt *Tester) AssertText(want string) {
+	tt.t.Helper()
+	if got := tt.Text(); got != want {
+		tt.t.Errorf("text mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+// AssertGolden compares the snapshot with testdata/<name>.golden.
+func (tt *Tester) AssertGolden(name string) {
+	tt.t.Helper()
+	AssertGolden(tt.t, name, tt.Snapshot())
+}
+
+// AssertGolden compares got with testdata/<name>.golden,
+// or writes got to the file when the -update flag is set.
+func AssertGolden(t testing.TB, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		err = os.WriteFile(path, []byte(got), 0644)
+		if err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
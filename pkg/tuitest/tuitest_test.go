@@ -0,0 +1,164 @@
+package tuitest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dytlzl/tervi/pkg/color"
+	"github.com/dytlzl/tervi/pkg/component"
+	"github.com/dytlzl/tervi/pkg/key"
+	"github.com/dytlzl/tervi/pkg/tui"
+)
+
+func TestGolden(t *testing.T) {
+	tt := New(t, func() *tui.View {
+		return tui.VStack(
+			tui.String("Hello").Bold().FGColor(color.Palette(196)),
+			tui.String("World").Title("TITLE").Border(),
+		)
+	}, 12, 6)
+	tt.AssertGolden("simple")
+}
+
+func TestPress(t *testing.T) {
+	selected := 0
+	items := []string{"apple", "banana", "cherry"}
+	tt := New(t, func() *tui.View {
+		return tui.ZStack(tui.ListMap(&selected, items, func(s string) *tui.View {
+			return tui.String(s)
+		})).KeyHandler(func(r rune) any {
+			if r == key.Enter {
+				return tui.Terminate
+			}
+			return nil
+		})
+	}, 10, 3)
+	tt.Press(key.ArrowDown, key.ArrowDown, key.ArrowUp)
+	if selected != 1 {
+		t.Errorf("selected = %d, want 1", selected)
+	}
+	tt.AssertGolden("list")
+	tt.Press(key.Enter)
+	if !tt.IsTerminated() {
+		t.Errorf("the screen is not terminated")
+	}
+}
+
+func TestPressKeys(t *testing.T) {
+	pressed := make([]string, 0)
+	tt := New(t, func() *tui.View {
+		return tui.String("").KeyEventHandler(func(e key.Event) any {
+			pressed = append(pressed, e.String())
+			if e.Is("ctrl+q") {
+				return tui.Terminate
+			}
+			return true
+		})
+	}, 10, 1)
+	tt.PressKeys("ctrl+shift+p", "shift+tab")
+	tt.Press(key.ArrowLeft|key.ModAlt, key.CtrlQ)
+	want := []string{"ctrl+shift+p", "shift+tab", "alt+left", "ctrl+q"}
+	if strings.Join(pressed, " ") != strings.Join(want, " ") {
+		t.Errorf("pressed = %q, want %q", pressed, want)
+	}
+	if !tt.IsTerminated() {
+		t.Errorf("the screen is not terminated")
+	}
+}
+
+func TestType(t *testing.T) {
+	input := ""
+	position := 0
+	tt := New(t, func() *tui.View {
+		return component.TextInput(&input, &position, func() {})
+	}, 10, 1)
+	tt.Type("héllo\x1b[D\x1b[DX")
+	tt.AssertText("hélXlo")
+	if input != "hélXlo" {
+		t.Errorf("input = %q, want %q", input, "hélXlo")
+	}
+}
+
+func TestPaste(t *testing.T) {
+	input := "ab"
+	position := 1
+	changed := 0
+	tt := New(t, func() *tui.View {
+		return component.TextInput(&input, &position, func() { changed++ })
+	}, 20, 1)
+	tt.Paste("x\ny\tz")
+	tt.AssertText("ax yzb")
+	if changed != 1 {
+		t.Errorf("changed = %d, want 1", changed)
+	}
+	if position != 5 {
+		t.Errorf("position = %d, want 5", position)
+	}
+
+	text := ""
+	position = 0
+	tt = New(t, func() *tui.View {
+		return component.TextField(&text, &position)
+	}, 20, 2)
+	tt.Type("\x1b[200~one\r\ntwo\x1b[201~")
+	tt.AssertText("one\ntwo")
+	if text != "one\ntwo" {
+		t.Errorf("text = %q, want %q", text, "one\ntwo")
+	}
+}
+
+func TestMouse(t *testing.T) {
+	selected := 0
+	items := []string{"apple", "banana", "cherry", "durian"}
+	tt := New(t, func() *tui.View {
+		return tui.ListMap(&selected, items, func(s string) *tui.View {
+			return tui.String(s)
+		}).Border()
+	}, 12, 6)
+	// The rows of the items are below the border, and the row above the bottom border is padding.
+	tt.Click(3, 4)
+	if selected != 0 {
+		t.Errorf("selected = %d after click on the padding, want 0", selected)
+	}
+	tt.Click(3, 3)
+	if selected != 1 {
+		t.Errorf("selected = %d after click, want 1", selected)
+	}
+	tt.Scroll(3, 3, -1)
+	if selected != 0 {
+		t.Errorf("selected = %d after scroll, want 0", selected)
+	}
+	tt.Scroll(3, 3, 5)
+	if selected != 3 {
+		t.Errorf("selected = %d after scroll, want 3", selected)
+	}
+	tt.AssertGolden("mouse")
+}
+
+func TestInline(t *testing.T) {
+	items := []string{"apple"}
+	tt := New(t, func() *tui.View {
+		return tui.VStack(
+			tui.String("Fruits:"),
+			tui.VMap(items, func(s string) *tui.View {
+				return tui.String("- " + s)
+			}).Border(),
+		)
+	}, 12, 8, tui.OptionInline())
+	tt.AssertText("Fruits:\n╭──────────╮\n│          │\n│ - apple  │\n│          │\n╰──────────╯")
+
+	items = append(items, "banana")
+	tt.Send(nil)
+	tt.AssertText("Fruits:\n╭──────────╮\n│          │\n│ - apple  │\n│ - banana │\n│          │\n╰──────────╯")
+
+	// The height is limited to the terminal.
+	items = append(items, "cherry", "durian")
+	tt.Send(nil)
+	if _, height := tt.Screen().Size(); height != 8 {
+		t.Errorf("height = %d, want 8", height)
+	}
+
+	items = items[:0]
+	tt.Send(nil)
+	tt.AssertText("Fruits:\n╭──────────╮\n│          │\n│          │\n╰──────────╯")
+}
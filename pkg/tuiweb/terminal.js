@@ -0,0 +1,664 @@
+'use strict';
+
+// Terminal draws the output of a program in an element, and sends the keys, the mouse events
+// and the pastes on the element as the input of the program. It interprets the escape sequences
+// that tervi writes, and its API is the subset of the one of xterm.js that the page uses.
+class Terminal {
+  constructor() {
+    this.cols = 80;
+    this.rows = 24;
+    this.dataListeners = [];
+    this.resizeListeners = [];
+    this.decoder = new TextDecoder();
+    // pending is the incomplete escape sequence at the end of the output written so far.
+    this.pending = '';
+    // modes are the private modes set by the program, e.g. 1006 for the SGR encoding of mouse events.
+    this.modes = new Set();
+    this.pen = defaultPen;
+    this.x = 0;
+    this.y = 0;
+    this.saved = { x: 0, y: 0 };
+    this.isCursorVisible = true;
+    this.lines = blankLines(this.cols, this.rows);
+    // normalLines are the lines of the normal screen while the alternate screen is shown.
+    this.normalLines = null;
+    this.element = null;
+    this.screen = null;
+    this.input = null;
+    this.cellWidth = 0;
+    this.cellHeight = 0;
+    this.pressedButton = -1;
+    this.isRenderScheduled = false;
+  }
+
+  onData(listener) {
+    this.dataListeners.push(listener);
+  }
+
+  onResize(listener) {
+    this.resizeListeners.push(listener);
+  }
+
+  // open shows the terminal in the element, and starts taking the keys typed on it.
+  open(element) {
+    this.element = element;
+    element.classList.add('terminal');
+    this.screen = document.createElement('div');
+    this.screen.className = 'terminal-screen';
+    element.appendChild(this.screen);
+
+    // The keys are typed into a hidden text area, so that input methods and pastes work.
+    this.input = document.createElement('textarea');
+    this.input.className = 'terminal-input';
+    this.input.setAttribute('autocapitalize', 'off');
+    this.input.setAttribute('autocorrect', 'off');
+    this.input.spellcheck = false;
+    element.appendChild(this.input);
+    this.input.addEventListener('keydown', (event) => this.handleKey(event));
+    // The text of an input method, or inserted without keys, e.g. from a list of emoji.
+    this.input.addEventListener('compositionend', (event) => {
+      this.send(event.data);
+      this.input.value = '';
+    });
+    this.input.addEventListener('input', (event) => {
+      if (!event.isComposing) {
+        this.send(this.input.value);
+        this.input.value = '';
+      }
+    });
+    this.input.addEventListener('paste', (event) => {
+      event.preventDefault();
+      this.paste(event.clipboardData.getData('text/plain'));
+    });
+
+    // The text on the screen can be selected while the program does not read the mouse.
+    element.addEventListener('mousedown', (event) => {
+      if (this.handleMouse(event, event.button, false)) {
+        event.preventDefault();
+      }
+    });
+    element.addEventListener('click', () => {
+      if (document.getSelection().isCollapsed) {
+        this.focus();
+      }
+    });
+    element.addEventListener('mouseup', (event) => this.handleMouse(event, event.button, true));
+    element.addEventListener('mousemove', (event) => this.handleMouse(event, -1, false));
+    element.addEventListener('wheel', (event) => {
+      if (this.handleMouse(event, event.deltaY < 0 ? 64 : 65, false)) {
+        event.preventDefault();
+      }
+    }, { passive: false });
+    element.addEventListener('contextmenu', (event) => {
+      if (this.modes.has(1000)) {
+        event.preventDefault();
+      }
+    });
+    this.render();
+  }
+
+  focus() {
+    this.input.focus({ preventScroll: true });
+  }
+
+  // fit resizes the terminal to fill its element.
+  fit() {
+    const probe = document.createElement('div');
+    const text = document.createElement('span');
+    text.textContent = 'W'.repeat(10);
+    probe.appendChild(text);
+    this.screen.appendChild(probe);
+    this.cellWidth = text.getBoundingClientRect().width / 10;
+    this.cellHeight = probe.getBoundingClientRect().height;
+    this.screen.removeChild(probe);
+    if (this.cellWidth === 0 || this.cellHeight === 0) {
+      return;
+    }
+    this.resize(
+      Math.max(1, Math.floor(this.element.clientWidth / this.cellWidth)),
+      Math.max(1, Math.floor(this.element.clientHeight / this.cellHeight)));
+  }
+
+  // resize changes the size of the terminal, keeping the cells that still fit.
+  resize(cols, rows) {
+    if (cols === this.cols && rows === this.rows) {
+      return;
+    }
+    const fitLines = (lines) => {
+      const resized = blankLines(cols, rows);
+      for (let y = 0; y < Math.min(rows, lines.length); y++) {
+        for (let x = 0; x < Math.min(cols, lines[y].length); x++) {
+          resized[y][x] = lines[y][x];
+        }
+      }
+      return resized;
+    };
+    this.lines = fitLines(this.lines);
+    if (this.normalLines) {
+      this.normalLines = fitLines(this.normalLines);
+    }
+    this.cols = cols;
+    this.rows = rows;
+    this.x = Math.min(this.x, cols - 1);
+    this.y = Math.min(this.y, rows - 1);
+    this.scheduleRender();
+    this.resizeListeners.forEach((listener) => listener({ cols, rows }));
+  }
+
+  // write interprets the output of the program, given as bytes of UTF-8 or as a string.
+  write(data) {
+    const text = this.pending + (typeof data === 'string' ? data : this.decoder.decode(data, { stream: true }));
+    this.pending = '';
+    let i = 0;
+    while (i < text.length) {
+      const c = text[i];
+      if (c !== '\x1b') {
+        i += this.writeChar(text, i);
+        continue;
+      }
+      const size = this.writeEscape(text, i);
+      if (size === 0) {
+        this.pending = text.slice(i);
+        break;
+      }
+      i += size;
+    }
+    this.scheduleRender();
+  }
+
+  // writeChar interprets the control character or prints the character at the index of the text.
+  // It returns the number of the UTF-16 code units read.
+  writeChar(text, i) {
+    const code = text.codePointAt(i);
+    const size = code > 0xffff ? 2 : 1;
+    switch (text[i]) {
+      case '\r':
+        this.x = 0;
+        return size;
+      case '\n':
+        this.lineFeed();
+        return size;
+      case '\b':
+        this.x = Math.max(0, Math.min(this.x, this.cols - 1) - 1);
+        return size;
+      case '\t':
+        this.x = Math.min(this.cols - 1, (Math.floor(this.x / 8) + 1) * 8);
+        return size;
+    }
+    if (code < 0x20 || code === 0x7f) {
+      return size;
+    }
+    const ch = String.fromCodePoint(code);
+    const width = charWidth(ch);
+    if (width === 0) {
+      // A combining character joins the previous character.
+      const x = Math.min(this.x, this.cols) - 1;
+      if (x >= 0) {
+        const cell = this.lines[this.y][x].width === 0 && x > 0 ? this.lines[this.y][x - 1] : this.lines[this.y][x];
+        cell.ch += ch;
+      }
+      return size;
+    }
+    // The line wraps when a character is printed past its end.
+    if (this.x + width > this.cols) {
+      this.x = 0;
+      this.lineFeed();
+    }
+    const line = this.lines[this.y];
+    // A wide character partly overwritten is erased.
+    if (line[this.x].width === 0 && this.x > 0) {
+      line[this.x - 1] = blankCell();
+    }
+    const last = this.x + width - 1;
+    if (line[last].width === 2 && last + 1 < this.cols) {
+      line[last + 1] = blankCell();
+    }
+    line[this.x] = { ch, width, pen: this.pen };
+    if (width === 2) {
+      line[this.x + 1] = { ch: '', width: 0, pen: this.pen };
+    }
+    this.x += width;
+    return size;
+  }
+
+  lineFeed() {
+    if (this.y < this.rows - 1) {
+      this.y++;
+      return;
+    }
+    this.lines.shift();
+    this.lines.push(blankLine(this.cols));
+  }
+
+  // writeEscape interprets the escape sequence at the index of the text.
+  // It returns the length of the sequence, or 0 if the sequence is incomplete.
+  writeEscape(text, i) {
+    if (i + 1 >= text.length) {
+      return 0;
+    }
+    switch (text[i + 1]) {
+      case '[':
+        break;
+      case '7':
+        this.saved = { x: this.x, y: this.y };
+        return 2;
+      case '8':
+        this.x = this.saved.x;
+        this.y = this.saved.y;
+        return 2;
+      default:
+        return 2;
+    }
+    let n = i + 2;
+    while (n < text.length && (text[n] < '\x40' || text[n] > '\x7e')) {
+      n++;
+    }
+    if (n === text.length) {
+      return 0;
+    }
+    this.writeCSI(text.slice(i + 2, n), text[n]);
+    return n + 1 - i;
+  }
+
+  writeCSI(params, final) {
+    const isPrivate = params.startsWith('?');
+    const values = (isPrivate ? params.slice(1) : params).split(';').map((p) => parseInt(p, 10) || 0);
+    const n = Math.max(1, values[0]);
+    if (final !== 'm' && final !== 'J') {
+      // The cursor past the end of the line after a character is printed is at the last column.
+      this.x = Math.min(this.x, this.cols - 1);
+    }
+    switch (final) {
+      case 'h':
+      case 'l':
+        if (isPrivate) {
+          values.forEach((mode) => this.setMode(mode, final === 'h'));
+        }
+        break;
+      case 'A':
+        this.y = Math.max(0, this.y - n);
+        break;
+      case 'B':
+        this.y = Math.min(this.rows - 1, this.y + n);
+        break;
+      case 'C':
+        this.x = Math.min(this.cols - 1, this.x + n);
+        break;
+      case 'D':
+        this.x = Math.max(0, this.x - n);
+        break;
+      case 'G':
+        this.x = Math.min(this.cols - 1, n - 1);
+        break;
+      case 'd':
+        this.y = Math.min(this.rows - 1, n - 1);
+        break;
+      case 'H':
+      case 'f':
+        this.y = Math.min(this.rows - 1, n - 1);
+        this.x = Math.min(this.cols - 1, Math.max(1, values[1] || 0) - 1);
+        break;
+      case 'J':
+        this.eraseDisplay(values[0]);
+        break;
+      case 'K':
+        this.eraseLine(this.y, values[0]);
+        break;
+      case 'm':
+        this.pen = applySGR(this.pen, values);
+        break;
+      case 's':
+        this.saved = { x: this.x, y: this.y };
+        break;
+      case 'u':
+        this.x = this.saved.x;
+        this.y = this.saved.y;
+        break;
+    }
+  }
+
+  setMode(mode, isSet) {
+    if (mode === 25) {
+      this.isCursorVisible = isSet;
+      return;
+    }
+    if (mode === 1049 && isSet !== this.modes.has(1049)) {
+      // The alternate screen is shown over the normal screen, which is shown again as it was.
+      if (isSet) {
+        this.saved = { x: this.x, y: this.y };
+        this.normalLines = this.lines;
+        this.lines = blankLines(this.cols, this.rows);
+      } else {
+        this.lines = this.normalLines;
+        this.normalLines = null;
+        this.x = this.saved.x;
+        this.y = this.saved.y;
+      }
+    }
+    if (isSet) {
+      this.modes.add(mode);
+    } else {
+      this.modes.delete(mode);
+    }
+  }
+
+  eraseDisplay(mode) {
+    if (mode === 2 || mode === 3) {
+      this.lines = blankLines(this.cols, this.rows);
+      return;
+    }
+    this.eraseLine(this.y, mode);
+    const [from, to] = mode === 1 ? [0, this.y] : [this.y + 1, this.rows];
+    for (let y = from; y < to; y++) {
+      this.lines[y] = blankLine(this.cols);
+    }
+  }
+
+  eraseLine(y, mode) {
+    const x = Math.min(this.x, this.cols - 1);
+    const [from, to] = mode === 1 ? [0, x + 1] : mode === 2 ? [0, this.cols] : [x, this.cols];
+    for (let i = from; i < to; i++) {
+      this.lines[y][i] = blankCell();
+    }
+  }
+
+  // text returns the characters on the screen, one line for each row.
+  text() {
+    return this.lines.map((line) => line.map((cell) => cell.ch).join('').trimEnd()).join('\n');
+  }
+
+  scheduleRender() {
+    if (!this.screen || this.isRenderScheduled) {
+      return;
+    }
+    this.isRenderScheduled = true;
+    requestAnimationFrame(() => {
+      this.isRenderScheduled = false;
+      this.render();
+    });
+  }
+
+  // render draws the lines as rows of spans, one for each run of cells of the same style.
+  render() {
+    const rows = this.lines.map((line, y) => {
+      const row = document.createElement('div');
+      let span = null;
+      let spanPen = null;
+      line.forEach((cell, x) => {
+        if (cell.width === 0) {
+          return;
+        }
+        const hasCursor = this.isCursorVisible && x === Math.min(this.x, this.cols - 1) && y === this.y;
+        const pen = hasCursor ? { ...cell.pen, reverse: !cell.pen.reverse } : cell.pen;
+        if (!span || pen !== spanPen || cell.width === 2 || hasCursor) {
+          span = document.createElement('span');
+          Object.assign(span.style, penStyle(pen));
+          if (cell.width === 2) {
+            span.className = 'wide';
+          }
+          row.appendChild(span);
+          spanPen = cell.width === 2 || hasCursor ? null : pen;
+        }
+        span.textContent += cell.ch;
+      });
+      return row;
+    });
+    this.screen.replaceChildren(...rows);
+  }
+
+  send(data) {
+    if (data) {
+      this.dataListeners.forEach((listener) => listener(data));
+    }
+  }
+
+  paste(text) {
+    text = text.replace(/\r?\n/g, '\r');
+    this.send(this.modes.has(2004) ? `\x1b[200~${text}\x1b[201~` : text);
+  }
+
+  handleKey(event) {
+    if (event.isComposing || event.keyCode === 229) {
+      return;
+    }
+    const data = encodeKey(event);
+    if (data === null) {
+      return;
+    }
+    event.preventDefault();
+    this.send(data);
+  }
+
+  // handleMouse sends the mouse event in the SGR encoding while the program enables the reporting.
+  // button is -1 for a move. It reports whether the event is sent.
+  handleMouse(event, button, isRelease) {
+    if (!this.modes.has(1000) || !this.modes.has(1006) || this.cellWidth === 0) {
+      return false;
+    }
+    const box = this.screen.getBoundingClientRect();
+    const x = Math.floor((event.clientX - box.left) / this.cellWidth);
+    const y = Math.floor((event.clientY - box.top) / this.cellHeight);
+    if (x < 0 || y < 0 || x >= this.cols || y >= this.rows) {
+      return false;
+    }
+    let code = button;
+    if (button === -1) {
+      // A move is reported only as a drag.
+      if (this.pressedButton === -1 || !this.modes.has(1002)) {
+        return false;
+      }
+      code = this.pressedButton + 32;
+    } else if (button < 64) {
+      this.pressedButton = isRelease ? -1 : button;
+    }
+    code |= (event.shiftKey ? 4 : 0) | (event.altKey ? 8 : 0) | (event.ctrlKey ? 16 : 0);
+    this.send(`\x1b[<${code};${x + 1};${y + 1}${isRelease ? 'm' : 'M'}`);
+    return true;
+  }
+}
+
+const defaultPen = Object.freeze({ fg: null, bg: null, bold: false, faint: false, italic: false, underline: false, reverse: false, strikethrough: false });
+
+const blankCell = () => ({ ch: ' ', width: 1, pen: defaultPen });
+
+const blankLine = (cols) => Array.from({ length: cols }, blankCell);
+
+const blankLines = (cols, rows) => Array.from({ length: rows }, () => blankLine(cols));
+
+// ansiColors are the 16 ANSI colors as xterm shows them by default.
+const ansiColors = [
+  '#000000', '#cd0000', '#00cd00', '#cdcd00', '#0000ee', '#cd00cd', '#00cdcd', '#e5e5e5',
+  '#7f7f7f', '#ff0000', '#00ff00', '#ffff00', '#5c5cff', '#ff00ff', '#00ffff', '#ffffff',
+];
+
+const defaultForeground = ansiColors[7];
+const defaultBackground = ansiColors[0];
+
+// paletteColor returns the color of the 256-color palette.
+const paletteColor = (n) => {
+  if (n < 16) {
+    return ansiColors[n];
+  }
+  if (n < 232) {
+    const level = (v) => (v === 0 ? 0 : 55 + v * 40);
+    n -= 16;
+    return `rgb(${level(Math.floor(n / 36))}, ${level(Math.floor(n / 6) % 6)}, ${level(n % 6)})`;
+  }
+  const gray = 8 + (n - 232) * 10;
+  return `rgb(${gray}, ${gray}, ${gray})`;
+};
+
+// applySGR returns the pen changed by the parameters of SGR (ESC [ ... m).
+const applySGR = (pen, values) => {
+  pen = { ...pen };
+  for (let i = 0; i < values.length; i++) {
+    const v = values[i];
+    if (v === 38 || v === 48) {
+      let color = null;
+      if (values[i + 1] === 5) {
+        color = paletteColor(values[i + 2] & 0xff);
+        i += 2;
+      } else if (values[i + 1] === 2) {
+        color = `rgb(${values[i + 2]}, ${values[i + 3]}, ${values[i + 4]})`;
+        i += 4;
+      }
+      pen[v === 38 ? 'fg' : 'bg'] = color;
+      continue;
+    }
+    switch (true) {
+      case v === 0:
+        pen = { ...defaultPen };
+        break;
+      case v === 1:
+        pen.bold = true;
+        break;
+      case v === 2:
+        pen.faint = true;
+        break;
+      case v === 3:
+        pen.italic = true;
+        break;
+      case v === 4:
+        pen.underline = true;
+        break;
+      case v === 7:
+        pen.reverse = true;
+        break;
+      case v === 9:
+        pen.strikethrough = true;
+        break;
+      case v === 22:
+        pen.bold = pen.faint = false;
+        break;
+      case v === 23:
+        pen.italic = false;
+        break;
+      case v === 24:
+        pen.underline = false;
+        break;
+      case v === 27:
+        pen.reverse = false;
+        break;
+      case v === 29:
+        pen.strikethrough = false;
+        break;
+      case v >= 30 && v <= 37:
+        pen.fg = ansiColors[v - 30];
+        break;
+      case v === 39:
+        pen.fg = null;
+        break;
+      case v >= 40 && v <= 47:
+        pen.bg = ansiColors[v - 40];
+        break;
+      case v === 49:
+        pen.bg = null;
+        break;
+      case v >= 90 && v <= 97:
+        pen.fg = ansiColors[v - 90 + 8];
+        break;
+      case v >= 100 && v <= 107:
+        pen.bg = ansiColors[v - 100 + 8];
+        break;
+    }
+  }
+  return Object.freeze(pen);
+};
+
+// penStyle returns the CSS properties of the cells drawn with the pen.
+const penStyle = (pen) => {
+  let fg = pen.fg || defaultForeground;
+  let bg = pen.bg || defaultBackground;
+  if (pen.reverse) {
+    [fg, bg] = [bg, fg];
+  }
+  const decorations = [];
+  if (pen.underline) {
+    decorations.push('underline');
+  }
+  if (pen.strikethrough) {
+    decorations.push('line-through');
+  }
+  return {
+    color: fg,
+    backgroundColor: bg,
+    fontWeight: pen.bold ? 'bold' : '',
+    fontStyle: pen.italic ? 'italic' : '',
+    opacity: pen.faint ? '0.5' : '',
+    textDecoration: decorations.join(' '),
+  };
+};
+
+// wideRanges are the ranges of the code points of the characters taking two columns.
+const wideRanges = [
+  [0x1100, 0x115f], [0x231a, 0x231b], [0x2329, 0x232a], [0x2e80, 0x303e], [0x3041, 0x33ff],
+  [0x3400, 0x4dbf], [0x4e00, 0x9fff], [0xa000, 0xa4cf], [0xa960, 0xa97f], [0xac00, 0xd7a3],
+  [0xf900, 0xfaff], [0xfe10, 0xfe19], [0xfe30, 0xfe6f], [0xff00, 0xff60], [0xffe0, 0xffe6],
+  [0x1f300, 0x1f64f], [0x1f900, 0x1f9ff], [0x20000, 0x3fffd],
+];
+
+// charWidth returns the number of the columns the character takes.
+const charWidth = (ch) => {
+  if (/^[\p{Mn}\p{Me}\u200b-\u200f]$/u.test(ch)) {
+    return 0;
+  }
+  const code = ch.codePointAt(0);
+  if (wideRanges.some(([from, to]) => code >= from && code <= to) || /^\p{Emoji_Presentation}$/u.test(ch)) {
+    return 2;
+  }
+  return 1;
+};
+
+// functionKeys maps the names of the keys to the final bytes or the numbers of their escape sequences.
+const functionKeys = {
+  ArrowUp: 'A', ArrowDown: 'B', ArrowRight: 'C', ArrowLeft: 'D', Home: 'H', End: 'F',
+  F1: 'P', F2: 'Q', F3: 'R', F4: 'S',
+  Insert: 2, Delete: 3, PageUp: 5, PageDown: 6,
+  F5: 15, F6: 17, F7: 18, F8: 19, F9: 20, F10: 21, F11: 23, F12: 24,
+};
+
+// controlKeys maps the keys typed with Ctrl besides the letters to their control characters.
+const controlKeys = { ' ': '\x00', '@': '\x00', '[': '\x1b', '\\': '\x1c', ']': '\x1d', '^': '\x1e', '_': '\x1f', '?': '\x7f' };
+
+// encodeKey returns the bytes that a terminal sends for the key, or null if the browser should handle it.
+const encodeKey = (event) => {
+  if (event.metaKey) {
+    return null;
+  }
+  const modifier = 1 + (event.shiftKey ? 1 : 0) + (event.altKey ? 2 : 0) + (event.ctrlKey ? 4 : 0);
+  const alt = event.altKey ? '\x1b' : '';
+  const final = functionKeys[event.key];
+  if (typeof final === 'number') {
+    return modifier > 1 ? `\x1b[${final};${modifier}~` : `\x1b[${final}~`;
+  }
+  if (final !== undefined) {
+    if (modifier > 1) {
+      return `\x1b[1;${modifier}${final}`;
+    }
+    return /[PQRS]/.test(final) ? `\x1bO${final}` : `\x1b[${final}`;
+  }
+  switch (event.key) {
+    case 'Enter':
+      return alt + '\r';
+    case 'Backspace':
+      return alt + (event.ctrlKey ? '\b' : '\x7f');
+    case 'Tab':
+      return event.shiftKey ? '\x1b[Z' : alt + '\t';
+    case 'Escape':
+      return alt + '\x1b';
+  }
+  if (event.key.length !== 1 && [...event.key].length !== 1) {
+    return null;
+  }
+  if (event.ctrlKey) {
+    // Ctrl+Shift with a letter is left to the browser, e.g. to paste with Ctrl+Shift+V.
+    if (event.shiftKey && /^[a-z]$/i.test(event.key)) {
+      return null;
+    }
+    if (/^[a-z]$/i.test(event.key)) {
+      return alt + String.fromCharCode(event.key.toUpperCase().charCodeAt(0) - 64);
+    }
+    return controlKeys[event.key] === undefined ? null : alt + controlKeys[event.key];
+  }
+  return alt + event.key;
+};
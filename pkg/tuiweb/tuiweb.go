@@ -0,0 +1,185 @@
+// Package tuiweb serves tervi programs to browsers.
+//
+// The handler serves a page with a terminal, and runs a program for each WebSocket
+// connection of the page on the same URL. The page sends the keys and the size of the terminal
+// as JSON text messages, and receives the output of the program as binary messages.
+// The script of the terminal is embedded in the package, so the page loads nothing from other sites.
+package tuiweb
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dytlzl/tervi/pkg/color"
+	"github.com/dytlzl/tervi/pkg/tui"
+	"github.com/gorilla/websocket"
+)
+
+// pageTemplate is the page with the terminal, into which terminalScript is inlined.
+//
+//go:embed index.html
+var pageTemplate string
+
+// terminalScript defines Terminal, which draws the output and sends the input like xterm.js.
+//
+//go:embed terminal.js
+var terminalScript string
+
+var page = renderPage()
+
+func renderPage() []byte {
+	t := template.Must(template.New("index.html").Parse(pageTemplate))
+	var b bytes.Buffer
+	err := t.Execute(&b, template.JS(terminalScript))
+	if err != nil {
+		panic(err)
+	}
+	return b.Bytes()
+}
+
+// maxMessageSize limits the size of the messages from the page, which are keys or pastes.
+const maxMessageSize = 1 << 20
+
+// message is a message sent from the page.
+type message struct {
+	Type    string `json:"type"` // "input" or "resize"
+	Data    string `json:"data"`
+	Columns int    `json:"columns"`
+	Rows    int    `json:"rows"`
+}
+
+// Handler returns a handler that serves the page, and runs the view returned by createView
+// like tui.Run for each connection of the page.
+// The states of hooks are kept per connection.
+// Connections from other origins than the page are refused.
+func Handler(createView func() *tui.View, options ...tui.Option) http.Handler {
+	return &handler{
+		createView: createView,
+		options:    options,
+	}
+}
+
+type handler struct {
+	createView func() *tui.View
+	options    []tui.Option
+	upgrader   websocket.Upgrader
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has replied with the error.
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(maxMessageSize)
+
+	t := &terminal{
+		conn:    conn,
+		ready:   make(chan struct{}),
+		closed:  make(chan struct{}),
+		resized: make(chan struct{}, 1),
+	}
+	input, inputWriter := io.Pipe()
+	// Closing the input stops readMessages after the program ends.
+	defer input.Close()
+	go t.readMessages(inputWriter)
+
+	// Wait for the size of the terminal.
+	select {
+	case <-t.ready:
+	case <-t.closed:
+		return
+	}
+	options := append([]tui.Option{
+		tui.OptionIO(input, t, t.size, t.resized),
+		tui.OptionColorProfile(color.TrueColor),
+	}, h.options...)
+	err = tui.Run(h.createView, options...)
+	if err != nil {
+		// The error is shown on the terminal of the page.
+		_, _ = fmt.Fprintf(t, "%s\r\n", strings.ReplaceAll(err.Error(), "\n", "\r\n"))
+	}
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
+
+// terminal is the terminal on the page connected by a WebSocket.
+type terminal struct {
+	conn      *websocket.Conn
+	mutex     sync.Mutex
+	width     int
+	height    int
+	ready     chan struct{} // closed when the size is received first
+	closed    chan struct{} // closed when the connection is closed
+	resized   chan struct{}
+	readyOnce sync.Once
+}
+
+// Write sends the output of the program to the page.
+func (t *terminal) Write(p []byte) (int, error) {
+	err := t.conn.WriteMessage(websocket.BinaryMessage, p)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *terminal) size() (int, int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.width, t.height, nil
+}
+
+func (t *terminal) resize(width, height int) {
+	t.mutex.Lock()
+	t.width, t.height = width, height
+	t.mutex.Unlock()
+	t.readyOnce.Do(func() {
+		close(t.ready)
+	})
+	select {
+	case t.resized <- struct{}{}:
+	default:
+	}
+}
+
+// readMessages writes the keys from the page to the input of the program until the connection is closed.
+func (t *terminal) readMessages(input *io.PipeWriter) {
+	defer close(t.closed)
+	defer input.Close()
+	for {
+		_, data, err := t.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var m message
+		if json.Unmarshal(data, &m) != nil {
+			continue
+		}
+		switch m.Type {
+		case "input":
+			_, err = io.WriteString(input, m.Data)
+			if err != nil {
+				return
+			}
+		case "resize":
+			if m.Columns > 0 && m.Rows > 0 {
+				t.resize(m.Columns, m.Rows)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package tuiweb
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dytlzl/tervi/pkg/tui"
+	"github.com/gorilla/websocket"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(Handler(func() *tui.View {
+		last, setLast := tui.UseState("none")
+		return tui.String("last=" + last).KeyHandler(func(r rune) any {
+			if r == 'q' {
+				return tui.Terminate
+			}
+			setLast(strings.Repeat(string(r), 4))
+			return true
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func connect(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	header := http.Header{"Origin": {server.URL}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readUntil reads the output until it contains s.
+func readUntil(t *testing.T, conn *websocket.Conn, s string) {
+	t.Helper()
+	output := ""
+	err := conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for !strings.Contains(output, s) {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("output does not contain %q: %q, %v", s, output, err)
+		}
+		if messageType != websocket.BinaryMessage {
+			t.Fatalf("message type = %d, want binary", messageType)
+		}
+		output += string(data)
+	}
+}
+
+func send(t *testing.T, conn *websocket.Conn, m message) {
+	t.Helper()
+	err := conn.WriteJSON(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler_page(t *testing.T) {
+	server := newServer(t)
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(body), "new Terminal()") {
+		t.Errorf("unexpected page: %d %s", res.StatusCode, body)
+	}
+	// The script of the terminal is inlined, and nothing is loaded from other sites.
+	if !strings.Contains(string(body), "class Terminal {") {
+		t.Error("the page does not contain the script of the terminal")
+	}
+	if strings.Contains(string(body), "src=") || strings.Contains(string(body), "href=") {
+		t.Errorf("the page loads assets: %s", body)
+	}
+}
+
+func TestHandler_socket(t *testing.T) {
+	server := newServer(t)
+	c1 := connect(t, server)
+	c2 := connect(t, server)
+
+	send(t, c1, message{Type: "resize", Columns: 20, Rows: 5})
+	readUntil(t, c1, "last=none")
+	send(t, c2, message{Type: "resize", Columns: 30, Rows: 8})
+	readUntil(t, c2, "last=none")
+
+	// Each connection has its own state.
+	send(t, c1, message{Type: "input", Data: "a"})
+	readUntil(t, c1, "aaaa")
+	send(t, c2, message{Type: "input", Data: "b"})
+	readUntil(t, c2, "bbbb")
+
+	// The screen is drawn again at the new size.
+	send(t, c1, message{Type: "resize", Columns: 25, Rows: 6})
+	readUntil(t, c1, "\x1b[H\x1b[2Jlast=aaaa")
+
+	send(t, c1, message{Type: "input", Data: "q"})
+	readUntil(t, c1, "\x1b[?1049l")
+	_, _, err := c1.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure {
+		t.Errorf("ReadMessage() error = %v, want normal closure", err)
+	}
+}
+
+func TestHandler_crossOrigin(t *testing.T) {
+	server := newServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	_, res, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://example.com"}})
+	if err == nil {
+		t.Fatal("the connection from another origin is accepted")
+	}
+	if res == nil || res.StatusCode != http.StatusForbidden {
+		t.Errorf("response = %v, want 403", res)
+	}
+}